@@ -0,0 +1,75 @@
+package jsonptr
+
+import (
+	"strconv"
+)
+
+// The methods in this file build new pointers from existing ones. Pointers
+// are never changed in place, so they can be shared and extended freely.
+
+// IsRoot returns true if the pointer refers to the whole document.
+func (p *Pointer) IsRoot() bool {
+	return len(p.path) == 0
+}
+
+// Last returns the unescaped last segment of the pointer, or "" for the root
+// pointer.
+func (p *Pointer) Last() string {
+	if len(p.path) == 0 {
+		return ""
+	}
+	return p.path[len(p.path)-1]
+}
+
+// Parent returns the pointer to the parent of the location, or the root
+// pointer if p is the root pointer.
+func (p *Pointer) Parent() *Pointer {
+	if len(p.path) == 0 {
+		return p
+	}
+	return p.slice(0, len(p.path)-1)
+}
+
+/*
+Child returns the pointer to the key of the object at p. The key is used as
+is, so "~" and "/" don't need to be escaped.
+
+    p := jsonptr.MustConstruct("/foo").Child("a/b")
+    // p.String() == "/foo/a~1b"
+*/
+func (p *Pointer) Child(key string) *Pointer {
+	return compile(childpath(p.path, key), p.strict)
+}
+
+// Index returns the pointer to the i'th element of the array at p.
+func (p *Pointer) Index(i int) *Pointer {
+	return compile(childpath(p.path, strconv.Itoa(i)), p.strict)
+}
+
+// Append returns the pointer to the "-" element of the array at p, which
+// appends to the array when it is set.
+func (p *Pointer) Append() *Pointer {
+	return compile(childpath(p.path, "-"), p.strict)
+}
+
+/*
+Concat returns a pointer to the location of other, relative to p.
+
+    p := jsonptr.MustConstruct("/foo").Concat(jsonptr.MustConstruct("/bar/0"))
+    // p.String() == "/foo/bar/0"
+*/
+func (p *Pointer) Concat(other *Pointer) *Pointer {
+	path := make([]string, len(p.path)+len(other.path))
+	copy(path, p.path)
+	copy(path[len(p.path):], other.path)
+	return compile(path, p.strict)
+}
+
+// slice returns the pointer to segments i to j of p, sharing its storage.
+func (p *Pointer) slice(i, j int) *Pointer {
+	res := &Pointer{path: p.path[i:j:j], strict: p.strict}
+	if p.indices != nil {
+		res.indices = p.indices[i:j:j]
+	}
+	return res
+}
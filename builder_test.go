@@ -0,0 +1,72 @@
+package jsonptr
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIsRootAndLast(t *testing.T) {
+	root := MustConstruct("")
+	assert.True(t, root.IsRoot())
+	assert.Equal(t, "", root.Last())
+
+	p := MustConstruct("/foo/a~1b")
+	assert.False(t, p.IsRoot())
+	assert.Equal(t, "a/b", p.Last())
+	assert.False(t, MustConstruct("/").IsRoot())
+}
+
+func TestParent(t *testing.T) {
+	p := MustConstruct("/foo/bar/0")
+	assert.Equal(t, "/foo/bar", p.Parent().String())
+	assert.Equal(t, "", p.Parent().Parent().Parent().String())
+	assert.True(t, p.Parent().Parent().Parent().Parent().IsRoot())
+	assert.Equal(t, "/foo/bar/0", p.String())
+}
+
+func TestChild(t *testing.T) {
+	p := MustConstruct("/foo")
+	assert.Equal(t, "/foo/a~1b", p.Child("a/b").String())
+	assert.Equal(t, "/foo/~0x", p.Child("~x").String())
+	assert.Equal(t, "/foo/", p.Child("").String())
+	assert.Equal(t, "/foo/2", p.Index(2).String())
+	assert.Equal(t, "/foo/-", p.Append().String())
+	assert.Equal(t, "/x", MustConstruct("").Child("x").String())
+	assert.Equal(t, "/foo", p.String())
+}
+
+func TestBuiltPointersAreIndependent(t *testing.T) {
+	// siblings built from a shared parent must not share storage
+	parent := MustConstruct("/a/b/c").Parent()
+	x := parent.Child("x")
+	y := parent.Child("y")
+	assert.Equal(t, "/a/b/x", x.String())
+	assert.Equal(t, "/a/b/y", y.String())
+
+	z := parent.Concat(MustConstruct("/z"))
+	assert.Equal(t, "/a/b/x", x.String())
+	assert.Equal(t, "/a/b/z", z.String())
+}
+
+func TestConcat(t *testing.T) {
+	p := MustConstruct("/foo")
+	assert.Equal(t, "/foo/bar/0", p.Concat(MustConstruct("/bar/0")).String())
+	assert.Equal(t, "/foo", p.Concat(MustConstruct("")).String())
+	assert.Equal(t, "/bar", MustConstruct("").Concat(MustConstruct("/bar")).String())
+}
+
+func TestBuiltPointerEvaluates(t *testing.T) {
+	doc := getZips()
+	p := MustConstruct("").Child("zipcodes")
+	assert.Equal(t, "AGAWAM", p.Index(0).Child("city").GetString(doc))
+}
+
+func ExamplePointer_Child() {
+	p := MustConstruct("/foo").Child("a/b").Index(0)
+	fmt.Println(p.String())
+	fmt.Println(p.Parent().Last())
+	// Output:
+	// /foo/a~1b/0
+	// a/b
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jessehansen/jsonptr"
+)
+
+func runGet(e *env, fs *flag.FlagSet, args []string) error {
+	raw := fs.Bool("raw", false, "print string values without quotes")
+	pretty := fs.Bool("pretty", false, "indent the output")
+	args, err := parseArgs(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	p, err := jsonptr.New(args[0])
+	if err != nil {
+		return syntaxError(err)
+	}
+	doc, err := e.readDocument(optional(args, 1))
+	if err != nil {
+		return err
+	}
+	if !p.Exists(doc) {
+		return notFound("%s not found", args[0])
+	}
+	val, err := p.Get(doc)
+	if err != nil {
+		return syntaxError(err)
+	}
+	if s, ok := val.(string); ok && *raw {
+		if _, err := fmt.Fprintln(e.stdout, s); err != nil {
+			return ioError(err)
+		}
+		return nil
+	}
+	return e.writeJSON(val, *pretty)
+}
+
+func runSet(e *env, fs *flag.FlagSet, args []string) error {
+	return runSetOrForce(e, fs, args, false)
+}
+
+func runForce(e *env, fs *flag.FlagSet, args []string) error {
+	return runSetOrForce(e, fs, args, true)
+}
+
+func runSetOrForce(e *env, fs *flag.FlagSet, args []string, force bool) error {
+	str := fs.Bool("string", false, "use VALUE as a string, even if it is valid JSON")
+	pretty := fs.Bool("pretty", false, "indent the output")
+	args, err := parseArgs(fs, args, 2, 3)
+	if err != nil {
+		return err
+	}
+	p, err := jsonptr.New(args[0])
+	if err != nil {
+		return syntaxError(err)
+	}
+	val := parseValue(args[1], *str)
+	doc, err := e.readDocument(optional(args, 2))
+	if err != nil {
+		return err
+	}
+	// a transaction can also change an array at the root, since its commit
+	// returns the new root
+	tx := jsonptr.NewTransaction(doc)
+	if force {
+		err = tx.Force(p, val)
+	} else {
+		err = tx.Set(p, val)
+	}
+	if err != nil {
+		if parent := p.Parent(); !force && !parent.Exists(doc) {
+			return notFound("%s not found", parent)
+		}
+		return syntaxError(err)
+	}
+	res, err := tx.Commit()
+	if err != nil {
+		return syntaxError(err)
+	}
+	return e.writeJSON(res, *pretty)
+}
+
+func runDelete(e *env, fs *flag.FlagSet, args []string) error {
+	pretty := fs.Bool("pretty", false, "indent the output")
+	args, err := parseArgs(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	p, err := jsonptr.New(args[0])
+	if err != nil {
+		return syntaxError(err)
+	}
+	doc, err := e.readDocument(optional(args, 1))
+	if err != nil {
+		return err
+	}
+	if !p.Exists(doc) {
+		return notFound("%s not found", args[0])
+	}
+	if p.IsRoot() {
+		return syntaxError(fmt.Errorf("Cannot delete root object"))
+	}
+	// a patch can also delete from an array at the root, by returning the
+	// new root
+	res, err := jsonptr.Patch{{Op: "remove", Path: p.String()}}.Apply(doc)
+	if err != nil {
+		return syntaxError(err)
+	}
+	return e.writeJSON(res, *pretty)
+}
+
+func runExists(e *env, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	p, err := jsonptr.New(args[0])
+	if err != nil {
+		return syntaxError(err)
+	}
+	doc, err := e.readDocument(optional(args, 1))
+	if err != nil {
+		return err
+	}
+	if !p.Exists(doc) {
+		return &exitError{exitNotFound, nil}
+	}
+	return nil
+}
+
+func runFlatten(e *env, fs *flag.FlagSet, args []string) error {
+	all := fs.Bool("all", false, "include every node, not just leaf nodes")
+	fragment := fs.Bool("fragment", false, "use URI fragment identifiers as keys")
+	records := fs.Bool("records", false, "print newline delimited {\"pointer\", \"value\"} records")
+	pretty := fs.Bool("pretty", false, "indent the output")
+	args, err := parseArgs(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	c := &jsonptr.Compactor{AllNodes: *all, URIFragment: *fragment}
+	if *records && !*all {
+		in, err := e.open(optional(args, 0))
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if err := c.StreamRecords(e.stdout, in); err != nil {
+			return in.fail(err)
+		}
+		return nil
+	}
+	doc, err := e.readDocument(optional(args, 0))
+	if err != nil {
+		return err
+	}
+	if *records {
+		if err := c.WriteRecords(e.stdout, doc); err != nil {
+			return ioError(err)
+		}
+		return nil
+	}
+	return e.writeJSON(c.Flatten(doc), *pretty)
+}
+
+func runExpand(e *env, fs *flag.FlagSet, args []string) error {
+	arrays := fs.Bool("arrays", false, "make objects with only index keys into arrays")
+	infer := fs.Bool("infer", false, "convert string values that are JSON literals")
+	records := fs.Bool("records", false, "read newline delimited {\"pointer\", \"value\"} records")
+	conflicts := fs.String("conflicts", "error", "how to handle conflicting keys: error, deeper or shallower")
+	pretty := fs.Bool("pretty", false, "indent the output")
+	args, err := parseArgs(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	x := &jsonptr.Expander{DetectArrays: *arrays}
+	if *infer {
+		x.Coerce = jsonptr.InferTypes
+	}
+	switch *conflicts {
+	case "error":
+		x.Conflicts = jsonptr.ConflictFail
+	case "deeper":
+		x.Conflicts = jsonptr.ConflictDeeperWins
+	case "shallower":
+		x.Conflicts = jsonptr.ConflictShallowerWins
+	default:
+		return syntaxError(fmt.Errorf("unknown conflict policy %q", *conflicts))
+	}
+
+	var doc interface{}
+	if *records {
+		in, err := e.open(optional(args, 0))
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if doc, err = x.ReadRecords(in); err != nil {
+			return in.fail(err)
+		}
+	} else {
+		in, err := e.readDocument(optional(args, 0))
+		if err != nil {
+			return err
+		}
+		values, ok := in.(map[string]interface{})
+		if !ok {
+			return syntaxError(fmt.Errorf("expected an object of pointers, got %T", in))
+		}
+		if doc, err = x.Expand(values); err != nil {
+			return syntaxError(err)
+		}
+	}
+	return e.writeJSON(doc, *pretty)
+}
+
+// parseValue parses a VALUE argument as JSON, or returns it as a string when
+// asString is true or it isn't valid JSON.
+func parseValue(arg string, asString bool) interface{} {
+	if asString {
+		return arg
+	}
+	var val interface{}
+	d := json.NewDecoder(strings.NewReader(arg))
+	d.UseNumber()
+	if err := d.Decode(&val); err != nil {
+		return arg
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return arg
+	}
+	return val
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/jessehansen/jsonptr"
+)
+
+const exploreHelp = `commands:
+  cd [PTR]           change the current location, or go to the root
+  ls [PTR]           list the children of a location with their types and sizes
+  get [PTR]          print the value at a location
+  set PTR VALUE      set the value at a location
+  delete PTR         delete the value at a location
+  undo               undo the last set or delete
+  pwd                print the current location
+  write [FILE]       write the document to FILE, or back to the file it was read from
+  complete [PTR]     list the completions of the last segment of PTR
+  help               print this help
+  quit               exit
+
+PTR is either absolute, starting with "/" or "#", or relative to the current
+location, where ".." is the parent. End a line with a tab, before pressing
+enter, to list the completions of its last word.
+`
+
+// explorer is the state of an explore session.
+type explorer struct {
+	*env
+	file   string
+	pretty bool
+	doc    interface{}
+	cwd    []string
+	undo   []jsonptr.Patch
+	dirty  bool
+}
+
+func runExplore(e *env, fs *flag.FlagSet, args []string) error {
+	pretty := fs.Bool("pretty", false, "indent the document when writing it")
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if args[0] == "-" {
+		return syntaxError(fmt.Errorf("cannot explore stdin, commands are read from it"))
+	}
+	doc, err := e.readDocument(args[0])
+	if err != nil {
+		return err
+	}
+	x := &explorer{env: e, file: args[0], pretty: *pretty, doc: doc, cwd: []string{}}
+	return x.loop()
+}
+
+func (x *explorer) loop() error {
+	scanner := bufio.NewScanner(x.stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for {
+		fmt.Fprintf(x.stdout, "%s> ", pointerOf(x.cwd).URIFragmentIdent())
+		if !scanner.Scan() {
+			break
+		}
+		line := scanner.Text()
+		if strings.HasSuffix(line, "\t") {
+			x.complete(lastWord(strings.TrimRight(line, "\t")))
+			continue
+		}
+		quit, err := x.exec(strings.TrimSpace(line))
+		if err != nil {
+			fmt.Fprintf(x.stdout, "error: %v\n", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+	fmt.Fprintln(x.stdout)
+	if err := scanner.Err(); err != nil {
+		return ioError(err)
+	}
+	if x.dirty {
+		// there's no one left to confirm quitting, so at least say so
+		fmt.Fprintf(x.stderr, "jsonptr explore: unsaved changes to %s were discarded\n", x.file)
+	}
+	return nil
+}
+
+// exec runs one command line, and reports whether the session should end.
+func (x *explorer) exec(line string) (bool, error) {
+	name, rest := splitWord(line)
+	switch name {
+	case "":
+		return false, nil
+	case "quit", "exit":
+		if x.dirty {
+			x.dirty = false
+			return false, fmt.Errorf("the document has unsaved changes, write it or quit again")
+		}
+		return true, nil
+	case "help":
+		fmt.Fprint(x.stdout, exploreHelp)
+	case "pwd":
+		fmt.Fprintln(x.stdout, pointerOf(x.cwd).URIFragmentIdent())
+	case "cd":
+		if rest == "" {
+			x.cwd = []string{}
+			return false, nil
+		}
+		path, val, err := x.lookup(rest)
+		if err != nil {
+			return false, err
+		}
+		if _, ok := val.(map[string]interface{}); !ok {
+			if _, ok := val.([]interface{}); !ok {
+				return false, fmt.Errorf("%s is a %s, not an object or array", pointerOf(path), jsonptr.TypeOf(val))
+			}
+		}
+		x.cwd = path
+	case "ls":
+		_, val, err := x.lookup(rest)
+		if err != nil {
+			return false, err
+		}
+		return false, x.list(val)
+	case "get":
+		_, val, err := x.lookup(rest)
+		if err != nil {
+			return false, err
+		}
+		return false, encodeJSON(x.stdout, val, true)
+	case "set":
+		arg, value := splitWord(rest)
+		if arg == "" || value == "" {
+			return false, fmt.Errorf("usage: set PTR VALUE")
+		}
+		path, err := x.resolve(arg)
+		if err != nil {
+			return false, err
+		}
+		return false, x.set(path, parseValue(value, false))
+	case "delete":
+		if rest == "" {
+			return false, fmt.Errorf("usage: delete PTR")
+		}
+		path, val, err := x.lookup(rest)
+		if err != nil {
+			return false, err
+		}
+		return false, x.delete(path, val)
+	case "undo":
+		return false, x.revert()
+	case "write":
+		path := rest
+		if path == "" {
+			path = x.file
+		}
+		if err := x.writeFile(path, x.doc, x.pretty); err != nil {
+			return false, err
+		}
+		x.dirty = false
+		fmt.Fprintf(x.stdout, "wrote %s\n", path)
+	case "complete":
+		x.complete(rest)
+	default:
+		return false, fmt.Errorf("unknown command %q, try help", name)
+	}
+	return false, nil
+}
+
+// resolve returns the path of a PTR argument, which is absolute when it
+// starts with "/" or "#", and relative to the current location otherwise.
+func (x *explorer) resolve(arg string) ([]string, error) {
+	if strings.HasPrefix(arg, "/") || strings.HasPrefix(arg, "#") {
+		p, err := jsonptr.New(arg)
+		if err != nil {
+			return nil, err
+		}
+		return p.Path(), nil
+	}
+	path := append([]string{}, x.cwd...)
+	if arg == "" {
+		return path, nil
+	}
+	for _, seg := range strings.Split(arg, "/") {
+		switch seg {
+		case ".":
+		case "..":
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		default:
+			path = append(path, strings.Replace(strings.Replace(seg, "~1", "/", -1), "~0", "~", -1))
+		}
+	}
+	return path, nil
+}
+
+// lookup resolves a PTR argument and returns its path and value. An empty
+// argument is the current location.
+func (x *explorer) lookup(arg string) ([]string, interface{}, error) {
+	path, err := x.resolve(arg)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := pointerOf(path)
+	if !p.Exists(x.doc) {
+		return nil, nil, fmt.Errorf("%s not found", p)
+	}
+	val, err := p.Get(x.doc)
+	return path, val, err
+}
+
+func (x *explorer) list(val interface{}) error {
+	w := tabwriter.NewWriter(x.stdout, 0, 8, 2, ' ', 0)
+	switch v := val.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", escapeSegment(k), jsonptr.TypeOf(v[k]), summarize(v[k]))
+		}
+	case []interface{}:
+		for i, it := range v {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", i, jsonptr.TypeOf(it), summarize(it))
+		}
+	default:
+		fmt.Fprintf(w, "%s\t%s\n", jsonptr.TypeOf(v), summarize(v))
+	}
+	return w.Flush()
+}
+
+// set sets the value at path, and records how to undo it.
+func (x *explorer) set(path []string, val interface{}) error {
+	p := pointerOf(path)
+	if len(path) == 0 {
+		return x.change(
+			jsonptr.Patch{{Op: "replace", Path: "", Value: val}},
+			jsonptr.Patch{{Op: "replace", Path: "", Value: x.doc}})
+	}
+	if p.Exists(x.doc) {
+		old, _ := p.Get(x.doc)
+		return x.change(
+			jsonptr.Patch{{Op: "replace", Path: p.String(), Value: val}},
+			jsonptr.Patch{{Op: "replace", Path: p.String(), Value: old}})
+	}
+	parent := p.Parent()
+	node, err := parent.Get(x.doc)
+	if err != nil {
+		return fmt.Errorf("%s not found", parent)
+	}
+	added := p
+	if arr, ok := node.([]interface{}); ok {
+		added = parent.Index(len(arr))
+		if seg := path[len(path)-1]; seg != "-" && seg != strconv.Itoa(len(arr)) {
+			return fmt.Errorf("%s is out of range, use %s or %s/- to append", p, added, parent)
+		}
+	}
+	return x.change(
+		jsonptr.Patch{{Op: "add", Path: added.String(), Value: val}},
+		jsonptr.Patch{{Op: "remove", Path: added.String()}})
+}
+
+// delete deletes the value at path, and records how to undo it.
+func (x *explorer) delete(path []string, old interface{}) error {
+	if len(path) == 0 {
+		return fmt.Errorf("cannot delete the root")
+	}
+	p := pointerOf(path)
+	if err := x.change(
+		jsonptr.Patch{{Op: "remove", Path: p.String()}},
+		jsonptr.Patch{{Op: "add", Path: p.String(), Value: old}}); err != nil {
+		return err
+	}
+	// step out of deleted locations
+	for !pointerOf(x.cwd).Exists(x.doc) {
+		x.cwd = x.cwd[:len(x.cwd)-1]
+	}
+	return nil
+}
+
+// change applies a patch, and pushes its inverse onto the undo stack.
+func (x *explorer) change(forward, inverse jsonptr.Patch) error {
+	doc, err := forward.Apply(x.doc)
+	if err != nil {
+		return err
+	}
+	x.doc = doc
+	x.undo = append(x.undo, inverse)
+	x.dirty = true
+	return nil
+}
+
+func (x *explorer) revert() error {
+	if len(x.undo) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	inverse := x.undo[len(x.undo)-1]
+	doc, err := inverse.Apply(x.doc)
+	if err != nil {
+		return err
+	}
+	x.doc = doc
+	x.undo = x.undo[:len(x.undo)-1]
+	x.dirty = true
+	for !pointerOf(x.cwd).Exists(x.doc) {
+		x.cwd = x.cwd[:len(x.cwd)-1]
+	}
+	fmt.Fprintf(x.stdout, "undid %s %s\n", inverse[0].Op, displayPointer(inverse[0].Path))
+	return nil
+}
+
+// complete prints the children of the location before the last "/" in arg
+// whose names start with the text after it. Objects and arrays are printed
+// with a trailing "/".
+func (x *explorer) complete(arg string) {
+	dir, prefix := "", arg
+	if i := strings.LastIndex(arg, "/"); i >= 0 {
+		dir, prefix = arg[:i+1], arg[i+1:]
+	}
+	base := strings.TrimSuffix(dir, "/")
+	if dir == "/" || dir == "#/" {
+		base = dir[:len(dir)-1]
+	}
+	var node interface{}
+	if base == "" && dir != "" {
+		node = x.doc
+	} else if _, val, err := x.lookup(base); err == nil {
+		node = val
+	}
+	var names []string
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for k, it := range v {
+			names = append(names, completion(escapeSegment(k), it))
+		}
+		sort.Strings(names)
+	case []interface{}:
+		for i, it := range v {
+			names = append(names, completion(strconv.Itoa(i), it))
+		}
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			fmt.Fprintln(x.stdout, dir+name)
+		}
+	}
+}
+
+func completion(name string, val interface{}) string {
+	switch val.(type) {
+	case map[string]interface{}, []interface{}:
+		return name + "/"
+	}
+	return name
+}
+
+// summarize describes a value in a few words for ls.
+func summarize(val interface{}) string {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return plural(len(v), "key")
+	case []interface{}:
+		return plural(len(v), "item")
+	}
+	out, _ := json.Marshal(val)
+	s := string(out)
+	if utf8.RuneCountInString(s) > 40 {
+		s = string([]rune(s)[:37]) + "..."
+	}
+	return s
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// pointerOf returns the pointer to a decoded path.
+func pointerOf(path []string) *jsonptr.Pointer {
+	p := jsonptr.MustConstruct("")
+	for _, seg := range path {
+		p = p.Child(seg)
+	}
+	return p
+}
+
+func escapeSegment(seg string) string {
+	return strings.Replace(strings.Replace(seg, "~", "~0", -1), "/", "~1", -1)
+}
+
+func displayPointer(ptr string) string {
+	if ptr == "" {
+		return "the root"
+	}
+	return ptr
+}
+
+// splitWord splits a line into its first word and the trimmed remainder.
+func splitWord(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+	return line[:i], strings.TrimSpace(line[i+1:])
+}
+
+func lastWord(line string) string {
+	if i := strings.LastIndexAny(line, " \t"); i >= 0 {
+		return line[i+1:]
+	}
+	return ""
+}
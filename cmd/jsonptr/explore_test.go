@@ -0,0 +1,145 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const exploreDoc = `{"zipcodes":[{"city":"AGAWAM","loc":[-72.622739,42.070206],"pop":15338},{"city":"BARRE","pop":4546}],"a/b":{}}`
+
+// prompt matches the prompts, and the newline printed at the end of stdin.
+var prompt = regexp.MustCompile(`#\S*> (\n\z)?`)
+
+// explore runs an explore session with the given command lines, and returns
+// the exit code and output with prompts removed.
+func explore(t *testing.T, path string, lines ...string) (int, string) {
+	code, stdout, stderr := runWith(strings.Join(lines, "\n")+"\n", "explore", path)
+	assert.Equal(t, "", stderr)
+	return code, prompt.ReplaceAllString(stdout, "")
+}
+
+func TestExploreNavigation(t *testing.T) {
+	path := writeTemp(t, "doc.json", exploreDoc)
+	code, stdout, _ := runWith("cd zipcodes/0\npwd\ncd ..\npwd\ncd\npwd\n", "explore", path)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "#> #/zipcodes/0> #/zipcodes/0\n#/zipcodes/0> #/zipcodes> #/zipcodes\n#/zipcodes> #> #\n#> \n", stdout)
+
+	_, out := explore(t, path, "cd /zipcodes/0/city", "cd missing", "cd a~1b", "pwd")
+	assert.Equal(t, "error: /zipcodes/0/city is a string, not an object or array\nerror: /missing not found\n#/a~1b\n", out)
+}
+
+func TestExploreList(t *testing.T) {
+	path := writeTemp(t, "doc.json", exploreDoc)
+	_, out := explore(t, path, "ls", "cd zipcodes", "ls 0", "ls", "ls 1/pop")
+	assert.Equal(t, ""+
+		"a~1b      object  0 keys\n"+
+		"zipcodes  array   2 items\n"+
+		"city  string  \"AGAWAM\"\n"+
+		"loc   array   2 items\n"+
+		"pop   number  15338\n"+
+		"0  object  3 keys\n"+
+		"1  object  2 keys\n"+
+		"number  4546\n", out)
+
+	_, out = explore(t, path, `set /s "`+strings.Repeat("x", 50)+`"`, "ls /s", "quit", "quit")
+	assert.Equal(t, "string  \""+strings.Repeat("x", 36)+"...\n"+
+		"error: the document has unsaved changes, write it or quit again\n", out)
+}
+
+func TestExploreGetSetUndo(t *testing.T) {
+	path := writeTemp(t, "doc.json", exploreDoc)
+	_, out := explore(t, path,
+		"cd zipcodes/1",
+		`set state "MA"`,
+		"set pop 5000",
+		"set /zipcodes/- {\"city\": \"BLANDFORD\"}",
+		"set /zipcodes/5 1",
+		"get",
+		"undo",
+		"get /zipcodes/2",
+		"undo",
+		"undo",
+		"get",
+		"undo",
+		"quit",
+		"quit")
+	assert.Equal(t, ""+
+		"error: /zipcodes/5 is out of range, use /zipcodes/3 or /zipcodes/- to append\n"+
+		"{\n  \"city\": \"BARRE\",\n  \"pop\": 5000,\n  \"state\": \"MA\"\n}\n"+
+		"undid remove /zipcodes/2\n"+
+		"error: /zipcodes/2 not found\n"+
+		"undid replace /zipcodes/1/pop\n"+
+		"undid remove /zipcodes/1/state\n"+
+		"{\n  \"city\": \"BARRE\",\n  \"pop\": 4546\n}\n"+
+		"error: nothing to undo\n"+
+		"error: the document has unsaved changes, write it or quit again\n", out)
+
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal(t, exploreDoc, string(content))
+}
+
+func TestExploreEOFUnsaved(t *testing.T) {
+	path := writeTemp(t, "doc.json", exploreDoc)
+	code, _, stderr := runWith("set /a~1b/x 1\n", "explore", path)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "jsonptr explore: unsaved changes to "+path+" were discarded\n", stderr)
+
+	code, _, stderr = runWith("set /a~1b/x 1\nwrite\n", "explore", path)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "", stderr)
+}
+
+func TestExploreDeleteAndWrite(t *testing.T) {
+	path := writeTemp(t, "doc.json", exploreDoc)
+	_, out := explore(t, path, "cd zipcodes/1", "delete /zipcodes/1", "pwd", "undo", "get 1/pop", "delete 1", "delete", "write", "quit")
+	assert.Equal(t, "#/zipcodes\nundid add /zipcodes/1\n4546\nerror: usage: delete PTR\nwrote "+path+"\n", out)
+
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal(t, `{"a/b":{},"zipcodes":[{"city":"AGAWAM","loc":[-72.622739,42.070206],"pop":15338}]}`+"\n", string(content))
+
+	_, out = explore(t, path, "set [1]", "set # [1]", "get", "undo", "get /zipcodes/0/pop", "quit", "quit")
+	assert.Equal(t, "error: usage: set PTR VALUE\n[\n  1\n]\nundid replace the root\n15338\n"+
+		"error: the document has unsaved changes, write it or quit again\n", out)
+}
+
+func TestExploreWriteNewFile(t *testing.T) {
+	path := writeTemp(t, "doc.json", exploreDoc)
+	newPath := filepath.Join(filepath.Dir(path), "new.json")
+	_, out := explore(t, path, "delete /zipcodes", "write "+newPath, "quit")
+	assert.Equal(t, "wrote "+newPath+"\n", out)
+
+	content, _ := ioutil.ReadFile(newPath)
+	assert.Equal(t, `{"a/b":{}}`+"\n", string(content))
+	info, err := os.Stat(newPath)
+	if assert.Nil(t, err) {
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	}
+}
+
+func TestExploreComplete(t *testing.T) {
+	path := writeTemp(t, "doc.json", exploreDoc)
+	_, out := explore(t, path, "cd \t", "get zipcodes/0/\t", "ls /zipcodes/0/c\t", "cd zipcodes", "complete ", "complete ../\t", "complete 9/")
+	assert.Equal(t, ""+
+		"a~1b/\nzipcodes/\n"+
+		"zipcodes/0/city\nzipcodes/0/loc/\nzipcodes/0/pop\n"+
+		"/zipcodes/0/city\n"+
+		"0/\n1/\n"+
+		"../a~1b/\n../zipcodes/\n", out)
+}
+
+func TestExploreErrors(t *testing.T) {
+	code, _, _ := runWith("", "explore")
+	assert.Equal(t, exitSyntax, code)
+
+	code, _, _ = runWith("", "explore", "-")
+	assert.Equal(t, exitSyntax, code)
+
+	path := writeTemp(t, "doc.json", exploreDoc)
+	_, out := explore(t, path, "frobnicate", "help")
+	assert.Contains(t, out, "error: unknown command \"frobnicate\", try help\ncommands:\n")
+}
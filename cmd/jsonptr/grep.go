@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jessehansen/jsonptr"
+)
+
+func runGrep(e *env, fs *flag.FlagSet, args []string) error {
+	re := fs.Bool("e", false, "treat PATTERN as a regular expression")
+	numeric := fs.Bool("range", false, "treat PATTERN as a numeric range MIN:MAX, either of which may be omitted")
+	in := fs.String("in", "values", "what to match: values, keys or all")
+	leaves := fs.Bool("leaves", false, "only match leaf nodes")
+	values := fs.Bool("values", false, "print each value after its pointer, separated by a tab")
+	args, err := parseArgs(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	m, err := parseMatcher(args[0], *re, *numeric)
+	if err != nil {
+		return syntaxError(err)
+	}
+	s := &jsonptr.Searcher{LeavesOnly: *leaves}
+	switch *in {
+	case "values":
+		s.Target = jsonptr.SearchValues
+	case "keys":
+		s.Target = jsonptr.SearchKeys
+	case "all":
+		s.Target = jsonptr.SearchAll
+	default:
+		return syntaxError(fmt.Errorf("unknown search target %q", *in))
+	}
+	doc, err := e.readDocument(optional(args, 1))
+	if err != nil {
+		return err
+	}
+	res := s.Search(doc, m)
+	for _, pv := range res {
+		if !*values {
+			if _, err := fmt.Fprintln(e.stdout, pv.Pointer.String()); err != nil {
+				return ioError(err)
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(e.stdout, "%s\t", pv.Pointer.String()); err != nil {
+			return ioError(err)
+		}
+		if err := e.writeJSON(pv.Value, false); err != nil {
+			return err
+		}
+	}
+	if len(res) == 0 {
+		return &exitError{exitNotFound, nil}
+	}
+	return nil
+}
+
+// parseMatcher builds the matcher for a PATTERN argument.
+func parseMatcher(pattern string, re, numeric bool) (jsonptr.Matcher, error) {
+	if re && numeric {
+		return nil, fmt.Errorf("-e and -range can't be used together")
+	}
+	if re {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return jsonptr.MatchRegexp(r), nil
+	}
+	if !numeric {
+		return jsonptr.MatchString(pattern), nil
+	}
+	i := strings.Index(pattern, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid range %q, expected MIN:MAX", pattern)
+	}
+	min, err := parseBound(pattern[:i], math.Inf(-1))
+	if err != nil {
+		return nil, err
+	}
+	max, err := parseBound(pattern[i+1:], math.Inf(1))
+	if err != nil {
+		return nil, err
+	}
+	return jsonptr.MatchRange(min, max), nil
+}
+
+// parseBound parses one end of a range, returning def when it is empty.
+func parseBound(s string, def float64) (float64, error) {
+	if s == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid range bound %q", s)
+	}
+	return f, nil
+}
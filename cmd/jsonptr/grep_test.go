@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const grepDoc = `{"zipcodes":[{"city":"AGAWAM","pop":15338},{"city":"BARRE","pop":4546,"near":"AGAWAM"}]}`
+
+func TestGrep(t *testing.T) {
+	code, stdout, _ := runWith(grepDoc, "grep", "AGAWAM")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "/zipcodes/0/city\n/zipcodes/1/near\n", stdout)
+
+	code, stdout, _ = runWith(grepDoc, "grep", "-e", "-values", "^B")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "/zipcodes/1/city\t\"BARRE\"\n", stdout)
+
+	code, stdout, _ = runWith(grepDoc, "grep", "-range", "10000:")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "/zipcodes/0/pop\n", stdout)
+
+	code, stdout, _ = runWith(grepDoc, "grep", "-in", "keys", "-values", "pop")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "/zipcodes/0/pop\t15338\n/zipcodes/1/pop\t4546\n", stdout)
+
+	code, stdout, _ = runWith(grepDoc, "grep", "-in", "all", "-e", "^near$|^BARRE$")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "/zipcodes/1/city\n/zipcodes/1/near\n", stdout)
+
+	code, stdout, _ = runWith(`{"a":{"a":1}}`, "grep", "-in", "keys", "-leaves", "a")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "/a/a\n", stdout)
+}
+
+func TestGrepErrors(t *testing.T) {
+	code, stdout, stderr := runWith(grepDoc, "grep", "CHICOPEE")
+	assert.Equal(t, exitNotFound, code)
+	assert.Equal(t, "", stdout+stderr)
+
+	code, _, _ = runWith(grepDoc, "grep", "-e", "(")
+	assert.Equal(t, exitSyntax, code)
+
+	code, _, stderr = runWith(grepDoc, "grep", "-range", "10")
+	assert.Equal(t, exitSyntax, code)
+	assert.Contains(t, stderr, "expected MIN:MAX")
+
+	code, _, _ = runWith(grepDoc, "grep", "-range", "a:b")
+	assert.Equal(t, exitSyntax, code)
+
+	code, _, _ = runWith(grepDoc, "grep", "-e", "-range", "1:2")
+	assert.Equal(t, exitSyntax, code)
+
+	code, _, _ = runWith(grepDoc, "grep", "-in", "everything", "x")
+	assert.Equal(t, exitSyntax, code)
+}
@@ -0,0 +1,338 @@
+/*
+Command jsonptr evaluates RFC 6901 JSON pointers against JSON documents.
+
+Usage:
+
+    jsonptr <command> [flags] [arguments]
+
+Documents are read from the FILE argument, or from stdin when FILE is omitted
+or "-". Results are written to stdout.
+
+The commands are:
+
+    get PTR [FILE]          print the value at PTR
+    set PTR VALUE [FILE]    set the value at PTR and print the document
+    force PTR VALUE [FILE]  like set, creating missing objects along the way
+    delete PTR [FILE]       delete the value at PTR and print the document
+    exists PTR [FILE]       exit with 0 if PTR exists, or 1 if it doesn't
+    flatten [FILE]          print an object of leaf pointers and their values
+    expand [FILE]           expand an object of pointers into a document
+    grep PATTERN [FILE]     print the pointers of values that match PATTERN
+    explore FILE            navigate and edit FILE interactively
+    diff A B                print an RFC 6902 JSON Patch from A to B
+    patch DOC PATCH         apply an RFC 6902 JSON Patch to DOC
+    merge BASE OVERLAY      apply OVERLAY to BASE as an RFC 7386 merge patch
+
+VALUE is parsed as JSON, and used as a string when it isn't valid JSON.
+
+PATTERN matches string values that are equal to it. With -e it is a regular
+expression, and with -range it is a numeric range like 10:20, 10: or :20.
+grep exits with 1 when nothing matches.
+
+explore reads commands like cd, ls, get, set and undo from stdin, one per
+line, so it works in any terminal. Run help at its prompt for the list.
+
+The patch and merge commands accept -i to write the result back to the DOC or
+BASE file, and diff, patch and merge accept -check to exit with 1 instead of
+printing anything to stdout when the result differs from the input, which is
+useful in CI.
+
+The exit status is 0 on success, 1 when a pointer is not found or nothing
+matches, 2 for usage and syntax errors, like invalid pointers or JSON, 3 for
+I/O errors, and 4 when a patch can't be applied.
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	exitOK       = 0
+	exitNotFound = 1
+	exitSyntax   = 2
+	exitIO       = 3
+	exitConflict = 4
+
+	// exitDiffers is returned in -check mode when documents differ.
+	exitDiffers = 1
+)
+
+// exitError is an error that determines the exit status of the command.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &exitError{exitNotFound, fmt.Errorf(format, args...)}
+}
+
+func syntaxError(err error) error {
+	return &exitError{exitSyntax, err}
+}
+
+func ioError(err error) error {
+	return &exitError{exitIO, err}
+}
+
+// env holds the streams that a command reads from and writes to.
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+type command struct {
+	name, args, summary string
+	run                 func(e *env, fs *flag.FlagSet, args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"get", "PTR [FILE]", "print the value at PTR", runGet},
+		{"set", "PTR VALUE [FILE]", "set the value at PTR and print the document", runSet},
+		{"force", "PTR VALUE [FILE]", "like set, creating missing objects along the way", runForce},
+		{"delete", "PTR [FILE]", "delete the value at PTR and print the document", runDelete},
+		{"exists", "PTR [FILE]", "exit with 0 if PTR exists, or 1 if it doesn't", runExists},
+		{"flatten", "[FILE]", "print an object of leaf pointers and their values", runFlatten},
+		{"expand", "[FILE]", "expand an object of pointers into a document", runExpand},
+		{"grep", "PATTERN [FILE]", "print the pointers of values that match PATTERN", runGrep},
+		{"explore", "FILE", "navigate and edit FILE interactively", runExplore},
+		{"diff", "A B", "print an RFC 6902 JSON Patch from A to B", runDiff},
+		{"patch", "DOC PATCH", "apply an RFC 6902 JSON Patch to DOC", runPatch},
+		{"merge", "BASE OVERLAY", "apply OVERLAY to BASE as an RFC 7386 merge patch", runMerge},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &env{stdin, stdout, stderr}
+	if len(args) == 0 {
+		usage(stderr)
+		return exitSyntax
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage(stdout)
+		return exitOK
+	}
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		fs.Usage = func() {
+			fmt.Fprintf(stderr, "usage: jsonptr %s [flags] %s\n", c.name, c.args)
+			fs.PrintDefaults()
+		}
+		err := c.run(e, fs, args[1:])
+		if err == nil {
+			return exitOK
+		}
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		if ee, ok := err.(*exitError); ok {
+			if ee.err != nil {
+				fmt.Fprintf(stderr, "jsonptr %s: %v\n", c.name, ee.err)
+			}
+			return ee.code
+		}
+		fmt.Fprintf(stderr, "jsonptr %s: %v\n", c.name, err)
+		return exitSyntax
+	}
+	fmt.Fprintf(stderr, "jsonptr: unknown command %q\n", args[0])
+	usage(stderr)
+	return exitSyntax
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: jsonptr <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %-18s %s\n", c.name, c.args, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'jsonptr <command> -h' for the flags of a command.")
+}
+
+// parseArgs parses the flags and checks that between min and max positional
+// arguments remain.
+func parseArgs(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, &exitError{exitSyntax, nil}
+	}
+	rest := fs.Args()
+	if len(rest) < min || len(rest) > max {
+		fs.Usage()
+		return nil, &exitError{exitSyntax, nil}
+	}
+	return rest, nil
+}
+
+// input is a document source that remembers the first error from reading,
+// so that I/O errors can be told apart from errors in what was read.
+type input struct {
+	r       io.Reader
+	c       io.Closer
+	path    string
+	readErr error
+}
+
+func (in *input) Read(p []byte) (int, error) {
+	n, err := in.r.Read(p)
+	if err != nil && err != io.EOF && in.readErr == nil {
+		in.readErr = err
+	}
+	return n, err
+}
+
+func (in *input) Close() error {
+	if in.c == nil {
+		return nil
+	}
+	return in.c.Close()
+}
+
+// fail classifies an error that occurred while processing the input.
+func (in *input) fail(err error) error {
+	if in.readErr != nil {
+		return ioError(in.readErr)
+	}
+	return syntaxError(fmt.Errorf("invalid input in %s: %v", displayName(in.path), err))
+}
+
+// open opens the file at path for reading, or returns stdin when path is ""
+// or "-".
+func (e *env) open(path string) (*input, error) {
+	if path == "" || path == "-" {
+		return &input{e.stdin, nil, path, nil}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, ioError(err)
+	}
+	return &input{f, f, path, nil}, nil
+}
+
+// readDocument reads a JSON document from the file at path, or from stdin
+// when path is "" or "-".
+func (e *env) readDocument(path string) (interface{}, error) {
+	var doc interface{}
+	err := e.readJSON(path, &doc)
+	return doc, err
+}
+
+// readJSON unmarshals JSON from the file at path, or from stdin when path is
+// "" or "-", into v. Numbers are decoded as json.Number, so that they are
+// written back without losing precision. The input must hold a single JSON
+// value.
+func (e *env) readJSON(path string, v interface{}) error {
+	in, err := e.open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	d := json.NewDecoder(in)
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return in.fail(err)
+	}
+	if _, err := d.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("unexpected data after the JSON value")
+		}
+		return in.fail(err)
+	}
+	return nil
+}
+
+// writeFile replaces the file at path with val as JSON, keeping its
+// permissions, or creates it if it doesn't exist.
+func (e *env) writeFile(path string, val interface{}, pretty bool) error {
+	if path == "" || path == "-" {
+		return syntaxError(fmt.Errorf("cannot edit stdin in place"))
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+	} else if !os.IsNotExist(err) {
+		return ioError(err)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return ioError(err)
+	}
+	defer os.Remove(f.Name())
+	if err := encodeJSON(f, val, pretty); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return ioError(err)
+	}
+	if err := os.Chmod(f.Name(), mode); err != nil {
+		return ioError(err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return ioError(err)
+	}
+	return nil
+}
+
+// writeJSON writes a value to stdout as JSON, followed by a newline.
+func (e *env) writeJSON(val interface{}, pretty bool) error {
+	return encodeJSON(e.stdout, val, pretty)
+}
+
+func encodeJSON(w io.Writer, val interface{}, pretty bool) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(val); err != nil {
+		if _, ok := err.(*json.UnsupportedValueError); ok {
+			return syntaxError(err)
+		}
+		return ioError(err)
+	}
+	return nil
+}
+
+func displayName(path string) string {
+	if path == "" || path == "-" {
+		return "stdin"
+	}
+	return path
+}
+
+// optional returns the i'th argument, or "" if there are not enough.
+func optional(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDoc = `{"name":"AGAWAM","loc":[-72.622739,42.070206],"pop":15338,"tags":{"a/b":true}}`
+
+// runWith runs the command with the given stdin, and returns the exit code,
+// stdout and stderr.
+func runWith(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func writeTemp(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUsage(t *testing.T) {
+	code, _, stderr := runWith("")
+	assert.Equal(t, exitSyntax, code)
+	assert.Contains(t, stderr, "usage: jsonptr")
+
+	code, stdout, _ := runWith("", "help")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "flatten")
+
+	code, _, stderr = runWith("", "bogus")
+	assert.Equal(t, exitSyntax, code)
+	assert.Contains(t, stderr, `unknown command "bogus"`)
+
+	code, _, stderr = runWith("", "get")
+	assert.Equal(t, exitSyntax, code)
+	assert.Contains(t, stderr, "usage: jsonptr get [flags] PTR [FILE]")
+
+	code, _, _ = runWith("", "get", "-nope", "/a")
+	assert.Equal(t, exitSyntax, code)
+
+	code, _, _ = runWith("", "get", "-h")
+	assert.Equal(t, exitOK, code)
+}
+
+func TestGet(t *testing.T) {
+	code, stdout, _ := runWith(testDoc, "get", "/name")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "\"AGAWAM\"\n", stdout)
+
+	code, stdout, _ = runWith(testDoc, "get", "-raw", "/name")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "AGAWAM\n", stdout)
+
+	code, stdout, _ = runWith(testDoc, "get", "/loc/1", "-")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "42.070206\n", stdout)
+
+	code, stdout, _ = runWith(testDoc, "get", "#/tags/a~1b")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "true\n", stdout)
+
+	code, stdout, _ = runWith(testDoc, "get", "-pretty", "/loc")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "[\n  -72.622739,\n  42.070206\n]\n", stdout)
+}
+
+func TestGetFile(t *testing.T) {
+	path := writeTemp(t, "doc.json", testDoc)
+	code, stdout, _ := runWith("", "get", "/pop", path)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "15338\n", stdout)
+}
+
+func TestGetErrors(t *testing.T) {
+	code, _, stderr := runWith(testDoc, "get", "/missing")
+	assert.Equal(t, exitNotFound, code)
+	assert.Contains(t, stderr, "/missing not found")
+
+	code, _, _ = runWith(testDoc, "get", "/loc/2")
+	assert.Equal(t, exitNotFound, code)
+
+	code, _, _ = runWith(testDoc, "get", "missing-slash")
+	assert.Equal(t, exitSyntax, code)
+
+	code, _, stderr = runWith(`{"a":`, "get", "/a")
+	assert.Equal(t, exitSyntax, code)
+	assert.Contains(t, stderr, "invalid input in stdin")
+
+	code, stdout, stderr := runWith(`{"a":1} garbage`, "get", "/a")
+	assert.Equal(t, exitSyntax, code)
+	assert.Equal(t, "", stdout)
+	assert.Contains(t, stderr, "invalid input in stdin")
+
+	code, _, _ = runWith(`{"a":1} {"a":2}`, "get", "/a")
+	assert.Equal(t, exitSyntax, code)
+
+	code, stdout, _ = runWith("{\"a\":1}\n\n", "get", "/a")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "1\n", stdout)
+
+	code, _, _ = runWith("", "get", "/a", filepath.Join(t.TempDir(), "missing.json"))
+	assert.Equal(t, exitIO, code)
+}
+
+func TestSet(t *testing.T) {
+	code, stdout, _ := runWith(testDoc, "set", "/pop", "20000")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"pop":20000`)
+
+	code, stdout, _ = runWith(testDoc, "set", "-string", "/pop", "20000")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"pop":"20000"`)
+
+	code, stdout, _ = runWith(testDoc, "set", "/state", "MA")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"state":"MA"`)
+
+	code, stdout, _ = runWith(testDoc, "set", "/loc/-", `{"x":1}`)
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"loc":[-72.622739,42.070206,{"x":1}]`)
+
+	code, stdout, _ = runWith(testDoc, "set", "", `[1]`)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "[1]\n", stdout)
+
+	code, _, stderr := runWith(testDoc, "set", "/a/b/c", "1")
+	assert.Equal(t, exitNotFound, code)
+	assert.Contains(t, stderr, "/a/b not found")
+
+	code, _, _ = runWith(testDoc, "set", "/name/x", "1")
+	assert.Equal(t, exitSyntax, code)
+
+	code, stdout, _ = runWith(`[1,2]`, "set", "/-", "3")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "[1,2,3]\n", stdout)
+
+	code, stdout, _ = runWith(`[1,2]`, "set", "/0", "3")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "[3,2]\n", stdout)
+
+	code, _, _ = runWith(`[1,2]`, "set", "/5", "3")
+	assert.Equal(t, exitSyntax, code)
+}
+
+func TestForce(t *testing.T) {
+	code, stdout, _ := runWith(testDoc, "force", "/a/b/c", "1")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"a":{"b":{"c":1}}`)
+
+	code, _, _ = runWith(testDoc, "force", "/name/x", "1")
+	assert.Equal(t, exitSyntax, code)
+
+	code, stdout, _ = runWith(`[1,2]`, "force", "/4", "3")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "[1,2,null,null,3]\n", stdout)
+
+	code, stdout, _ = runWith(`[1,2]`, "force", "/-/a", "3")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "[1,2,{\"a\":3}]\n", stdout)
+}
+
+func TestDelete(t *testing.T) {
+	code, stdout, _ := runWith(testDoc, "delete", "/loc/0")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"loc":[42.070206]`)
+
+	code, _, _ = runWith(testDoc, "delete", "/missing")
+	assert.Equal(t, exitNotFound, code)
+
+	code, _, _ = runWith(testDoc, "delete", "")
+	assert.Equal(t, exitSyntax, code)
+	code, stdout, _ = runWith(`[1,2,{"a":3}]`, "delete", "/0")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "[2,{\"a\":3}]\n", stdout)
+}
+
+func TestExists(t *testing.T) {
+	code, stdout, stderr := runWith(testDoc, "exists", "/loc/1")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "", stdout+stderr)
+
+	code, stdout, stderr = runWith(testDoc, "exists", "/loc/2")
+	assert.Equal(t, exitNotFound, code)
+	assert.Equal(t, "", stdout+stderr)
+}
+
+func TestFlatten(t *testing.T) {
+	code, stdout, _ := runWith(testDoc, "flatten")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"/loc/0":-72.622739,"/loc/1":42.070206,"/name":"AGAWAM","/pop":15338,"/tags/a~1b":true}`+"\n", stdout)
+
+	code, stdout, _ = runWith(`{"a":[1]}`, "flatten", "-all", "-fragment")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"#":{"a":[1]},"#/a":[1],"#/a/0":1}`+"\n", stdout)
+
+	code, stdout, _ = runWith(`{"a":[1, 2.50]}`, "flatten", "-records")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"pointer":"/a/0","value":1}`+"\n"+`{"pointer":"/a/1","value":2.50}`+"\n", stdout)
+
+	code, _, _ = runWith(`{"a":[1,`, "flatten", "-records")
+	assert.Equal(t, exitSyntax, code)
+}
+
+func TestExpand(t *testing.T) {
+	code, stdout, _ := runWith(`{"/a/0":"x","/a/1":"true"}`, "expand")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"a":{"0":"x","1":"true"}}`+"\n", stdout)
+
+	code, stdout, _ = runWith(`{"/a/0":"x","/a/1":"true"}`, "expand", "-arrays", "-infer")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"a":["x",true]}`+"\n", stdout)
+
+	code, stdout, _ = runWith(`{"pointer":"/a/0","value":1}`+"\n", "expand", "-records", "-arrays")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"a":[1]}`+"\n", stdout)
+
+	code, _, stderr := runWith(`{"/a":1,"/a/b":2}`, "expand")
+	assert.Equal(t, exitSyntax, code)
+	assert.Contains(t, stderr, "conflicting keys")
+
+	code, stdout, _ = runWith(`{"/a":1,"/a/b":2}`, "expand", "-conflicts", "deeper")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"a":{"b":2}}`+"\n", stdout)
+
+	code, _, _ = runWith(`{}`, "expand", "-conflicts", "newest")
+	assert.Equal(t, exitSyntax, code)
+
+	code, _, _ = runWith(`[1]`, "expand")
+	assert.Equal(t, exitSyntax, code)
+}
+
+func TestReadError(t *testing.T) {
+	code, _, _ := runWith("", "get", "/a", t.TempDir())
+	assert.Equal(t, exitIO, code)
+}
+
+func TestLargeNumbers(t *testing.T) {
+	doc := `{"id":9007199254740993,"ratio":1.10}`
+	code, stdout, _ := runWith(doc, "get", "/id")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "9007199254740993\n", stdout)
+
+	code, stdout, _ = runWith(doc, "set", "/next", "9007199254740995")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"id":9007199254740993,"next":9007199254740995,"ratio":1.10}`+"\n", stdout)
+
+	code, stdout, _ = runWith(doc, "flatten")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"/id":9007199254740993,"/ratio":1.10}`+"\n", stdout)
+
+	code, stdout, _ = runWith(doc, "set", "/s", "1 2")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"s":"1 2"`)
+}
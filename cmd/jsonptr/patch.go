@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/jessehansen/jsonptr"
+)
+
+func runDiff(e *env, fs *flag.FlagSet, args []string) error {
+	check := fs.Bool("check", false, "exit with 1 if A and B differ, instead of printing the patch")
+	pretty := fs.Bool("pretty", false, "indent the output")
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	a, err := e.readDocument(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := e.readDocument(args[1])
+	if err != nil {
+		return err
+	}
+	p := jsonptr.Diff(a, b)
+	if *check {
+		return checkDiffers(args[0], args[1], len(p) > 0)
+	}
+	return e.writeJSON(p, *pretty)
+}
+
+func runPatch(e *env, fs *flag.FlagSet, args []string) error {
+	inPlace := fs.Bool("i", false, "write the result to DOC instead of stdout")
+	check := fs.Bool("check", false, "exit with 1 if the patch changes DOC, instead of printing the result")
+	pretty := fs.Bool("pretty", false, "indent the output")
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	doc, err := e.readDocument(args[0])
+	if err != nil {
+		return err
+	}
+	var p jsonptr.Patch
+	if err := e.readJSON(args[1], &p); err != nil {
+		return err
+	}
+	res, err := p.Apply(doc)
+	if err != nil {
+		return &exitError{exitConflict, err}
+	}
+	return e.output(args[0], doc, res, *inPlace, *check, *pretty)
+}
+
+func runMerge(e *env, fs *flag.FlagSet, args []string) error {
+	inPlace := fs.Bool("i", false, "write the result to BASE instead of stdout")
+	check := fs.Bool("check", false, "exit with 1 if the merge changes BASE, instead of printing the result")
+	pretty := fs.Bool("pretty", false, "indent the output")
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	base, err := e.readDocument(args[0])
+	if err != nil {
+		return err
+	}
+	overlay, err := e.readDocument(args[1])
+	if err != nil {
+		return err
+	}
+	res := jsonptr.MergePatch(base, overlay)
+	return e.output(args[0], base, res, *inPlace, *check, *pretty)
+}
+
+// output writes the result of changing the document at path, either to
+// stdout, back to path, or as a -check exit status.
+func (e *env) output(path string, before, after interface{}, inPlace, check, pretty bool) error {
+	if check {
+		return checkDiffers(path, "the result", len(jsonptr.Diff(before, after)) > 0)
+	}
+	if inPlace {
+		return e.writeFile(path, after, pretty)
+	}
+	return e.writeJSON(after, pretty)
+}
+
+func checkDiffers(a, b string, differs bool) error {
+	if differs {
+		return &exitError{exitDiffers, fmt.Errorf("%s and %s differ", displayName(a), displayName(b))}
+	}
+	return nil
+}
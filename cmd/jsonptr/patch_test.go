@@ -0,0 +1,110 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := writeTemp(t, "a.json", `{"a":1,"b":[1]}`)
+	b := writeTemp(t, "b.json", `{"b":[1,2],"c":3}`)
+
+	code, stdout, _ := runWith("", "diff", a, b)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `[{"op":"remove","path":"/a"},{"op":"add","path":"/b/1","value":2},{"op":"add","path":"/c","value":3}]`+"\n", stdout)
+
+	code, stdout, _ = runWith(`{"a":1,"b":[1]}`, "diff", "-", a)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "[]\n", stdout)
+
+	code, stdout, stderr := runWith("", "diff", "-check", a, b)
+	assert.Equal(t, exitDiffers, code)
+	assert.Equal(t, "", stdout)
+	assert.Contains(t, stderr, "differ")
+
+	code, _, _ = runWith("", "diff", "-check", a, a)
+	assert.Equal(t, exitOK, code)
+
+	code, _, _ = runWith("", "diff", a)
+	assert.Equal(t, exitSyntax, code)
+}
+
+func TestPatch(t *testing.T) {
+	doc := writeTemp(t, "doc.json", `{"a":[1,2]}`)
+	ops := writeTemp(t, "ops.json", `[{"op":"add","path":"/a/0","value":0},{"op":"remove","path":"/a/2"}]`)
+
+	code, stdout, _ := runWith("", "patch", doc, ops)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"a":[0,1]}`+"\n", stdout)
+
+	code, _, _ = runWith("", "patch", "-check", doc, ops)
+	assert.Equal(t, exitDiffers, code)
+
+	code, stdout, _ = runWith("", "patch", "-i", "-pretty", doc, ops)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "", stdout)
+	content, _ := ioutil.ReadFile(doc)
+	assert.Equal(t, "{\n  \"a\": [\n    0,\n    1\n  ]\n}\n", string(content))
+
+	test := writeTemp(t, "test.json", `[{"op":"test","path":"/a/0","value":0}]`)
+	code, _, _ = runWith("", "patch", "-check", doc, test)
+	assert.Equal(t, exitOK, code)
+}
+
+func TestPatchErrors(t *testing.T) {
+	doc := writeTemp(t, "doc.json", `{"a":[1,2]}`)
+	failing := writeTemp(t, "failing.json", `[{"op":"test","path":"/a/0","value":5}]`)
+	code, _, stderr := runWith("", "patch", doc, failing)
+	assert.Equal(t, exitConflict, code)
+	assert.Contains(t, stderr, "Test failed")
+
+	invalid := writeTemp(t, "invalid.json", `{"op":"add"}`)
+	code, _, _ = runWith("", "patch", doc, invalid)
+	assert.Equal(t, exitSyntax, code)
+
+	ops := writeTemp(t, "ops.json", `[]`)
+	code, _, stderr = runWith(`{}`, "patch", "-i", "-", ops)
+	assert.Equal(t, exitSyntax, code)
+	assert.Contains(t, stderr, "cannot edit stdin in place")
+}
+
+func TestMerge(t *testing.T) {
+	base := writeTemp(t, "base.json", `{"a":1,"b":{"c":2}}`)
+	overlay := writeTemp(t, "overlay.json", `{"a":null,"b":{"d":3}}`)
+
+	code, stdout, _ := runWith("", "merge", base, overlay)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `{"b":{"c":2,"d":3}}`+"\n", stdout)
+
+	code, _, _ = runWith("", "merge", "-check", base, overlay)
+	assert.Equal(t, exitDiffers, code)
+
+	info, _ := os.Stat(base)
+	os.Chmod(base, 0600)
+	code, _, _ = runWith("", "merge", "-i", base, overlay)
+	assert.Equal(t, exitOK, code)
+	content, _ := ioutil.ReadFile(base)
+	assert.Equal(t, `{"b":{"c":2,"d":3}}`+"\n", string(content))
+	after, _ := os.Stat(base)
+	assert.Equal(t, os.FileMode(0600), after.Mode().Perm())
+	assert.NotEqual(t, info.Mode().Perm(), after.Mode().Perm())
+
+	code, _, _ = runWith("", "merge", "-check", base, overlay)
+	assert.Equal(t, exitOK, code)
+}
+
+func TestDiffNumbers(t *testing.T) {
+	a := writeTemp(t, "a.json", `{"id":9007199254740993,"n":1.0}`)
+	b := writeTemp(t, "b.json", `{"id":9007199254740992,"n":1}`)
+	code, stdout, _ := runWith("", "diff", a, b)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `[{"op":"replace","path":"/id","value":9007199254740992}]`+"\n", stdout)
+
+	ops := writeTemp(t, "ops.json", `[{"op":"test","path":"/id","value":9007199254740993},{"op":"test","path":"/n","value":1}]`)
+	code, _, _ = runWith("", "patch", "-check", a, ops)
+	assert.Equal(t, exitOK, code)
+	code, _, _ = runWith("", "patch", b, ops)
+	assert.Equal(t, exitConflict, code)
+}
@@ -0,0 +1,215 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Coercer converts a value before an Expander adds it to the document at
+// the location p. It is typically used to convert strings from sources that
+// can't represent other types, like environment variables or query strings.
+type Coercer func(p *Pointer, val interface{}) (interface{}, error)
+
+// ValueType is the type of a JSON value.
+type ValueType int
+
+const (
+	// AnyType matches any value.
+	AnyType ValueType = iota
+	// NullType matches nil.
+	NullType
+	// BoolType matches bool values.
+	BoolType
+	// NumberType matches float64, json.Number, and go integer values.
+	NumberType
+	// StringType matches string values.
+	StringType
+	// ArrayType matches []interface{} values.
+	ArrayType
+	// ObjectType matches map[string]interface{} values.
+	ObjectType
+)
+
+var valueTypeNames = []string{"any", "null", "bool", "number", "string", "array", "object"}
+
+func (t ValueType) String() string {
+	if t < 0 || int(t) >= len(valueTypeNames) {
+		return fmt.Sprintf("ValueType(%d)", int(t))
+	}
+	return valueTypeNames[t]
+}
+
+// TypeOf returns the ValueType of a value, or AnyType if the value is not a
+// JSON value.
+func TypeOf(val interface{}) ValueType {
+	switch val.(type) {
+	case nil:
+		return NullType
+	case bool:
+		return BoolType
+	case float64, float32, json.Number,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return NumberType
+	case string:
+		return StringType
+	case []interface{}:
+		return ArrayType
+	case map[string]interface{}:
+		return ObjectType
+	}
+	return AnyType
+}
+
+// numberValue converts a JSON number to a float64.
+func numberValue(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		return f, err == nil
+	case int, int8, int16, int32, int64:
+		return float64(reflect.ValueOf(v).Int()), true
+	case uint, uint8, uint16, uint32, uint64:
+		return float64(reflect.ValueOf(v).Uint()), true
+	}
+	return 0, false
+}
+
+// numberRat converts a JSON number to a big.Rat, so that numbers of
+// different types can be compared exactly.
+func numberRat(val interface{}) (*big.Rat, bool) {
+	switch v := val.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(v), true
+	case float32:
+		return numberRat(float64(v))
+	case json.Number:
+		return new(big.Rat).SetString(string(v))
+	case int, int8, int16, int32, int64:
+		return new(big.Rat).SetInt64(reflect.ValueOf(v).Int()), true
+	case uint, uint8, uint16, uint32, uint64:
+		return new(big.Rat).SetUint64(reflect.ValueOf(v).Uint()), true
+	}
+	return nil, false
+}
+
+/*
+InferTypes is a Coercer that converts string values which are JSON literals
+into the value they represent. "null", "true", "false", numbers, arrays and
+objects are converted. JSON strings, and strings that are not JSON or that
+have leading or trailing whitespace, are left unchanged. Numbers are converted
+to float64, except integers that a float64 can't hold exactly, such as
+"9007199254740993", which are converted to json.Number.
+
+    e := &jsonptr.Expander{Coerce: jsonptr.InferTypes}
+    doc, _ := e.Expand(map[string]interface{}{"/port": "8080", "/debug": "true"})
+    // doc is map[debug:true port:8080], with a bool and a float64
+*/
+func InferTypes(p *Pointer, val interface{}) (interface{}, error) {
+	s, ok := val.(string)
+	if !ok || s == "" || strings.TrimSpace(s) != s {
+		return val, nil
+	}
+	res, err := decodeJSON(s)
+	if err != nil {
+		return val, nil
+	}
+	if _, ok := res.(string); ok {
+		return val, nil
+	}
+	return res, nil
+}
+
+// decodeJSON decodes a single JSON value from s. Numbers are decoded as
+// float64 when that is exact, and as json.Number otherwise, so large
+// integers are not rounded.
+func decodeJSON(s string) (interface{}, error) {
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	var res interface{}
+	if err := d.Decode(&res); err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, fmt.Errorf("Unexpected data after JSON value")
+	}
+	return floatNumbers(res), nil
+}
+
+// floatNumbers replaces the json.Number values in val with float64 values,
+// where they are exact.
+func floatNumbers(val interface{}) interface{} {
+	switch v := val.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil || !exactFloat(v, f, 64) {
+			return v
+		}
+		return f
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = floatNumbers(elem)
+		}
+	case map[string]interface{}:
+		for k, elem := range v {
+			v[k] = floatNumbers(elem)
+		}
+	}
+	return val
+}
+
+/*
+Schema maps locations, in Pointer.String form, to the type expected there.
+Its Coerce method is a Coercer which converts string values to the expected
+type, and returns an error when a value can't be converted. Values at
+locations that are not in the Schema are left unchanged.
+
+    s := jsonptr.Schema{"/port": jsonptr.NumberType, "/name": jsonptr.StringType}
+    e := &jsonptr.Expander{Coerce: s.Coerce}
+*/
+type Schema map[string]ValueType
+
+// Coerce converts val to the type expected at the location p. Numbers,
+// arrays and objects are decoded from JSON the same way as InferTypes.
+func (s Schema) Coerce(p *Pointer, val interface{}) (interface{}, error) {
+	expected, ok := s[p.String()]
+	if !ok || expected == AnyType {
+		return val, nil
+	}
+	if TypeOf(val) == expected {
+		return val, nil
+	}
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("Cannot coerce %s to %s at '%s'", TypeOf(val), expected, p)
+	}
+
+	switch expected {
+	case NullType:
+		if str == "null" || str == "" {
+			return nil, nil
+		}
+	case BoolType:
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b, nil
+		}
+	case NumberType, ArrayType, ObjectType:
+		if res, err := decodeJSON(str); err == nil && TypeOf(res) == expected {
+			return res, nil
+		}
+	}
+	return nil, fmt.Errorf("Cannot coerce '%s' to %s at '%s'", str, expected, p)
+}
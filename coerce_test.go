@@ -0,0 +1,169 @@
+package jsonptr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInferTypes(t *testing.T) {
+	p := MustConstruct("/a")
+	cases := map[string]interface{}{
+		"null":       nil,
+		"true":       true,
+		"false":      false,
+		"12":         12.0,
+		"-1.5e3":     -1500.0,
+		"[1,\"a\"]":  []interface{}{1.0, "a"},
+		"{\"b\":1}":  map[string]interface{}{"b": 1.0},
+		"\"quoted\"": "\"quoted\"",
+		"hello":      "hello",
+		"":           "",
+		" 12":        " 12",
+		"12 ":        "12 ",
+		"0x10":       "0x10",
+		"NaN":        "NaN",
+		"True":       "True",
+		"1 2":        "1 2",
+
+		"9007199254740992":           9007199254740992.0,
+		"9007199254740993":           json.Number("9007199254740993"),
+		"[9007199254740993]":         []interface{}{json.Number("9007199254740993")},
+		"{\"id\":-9007199254740993}": map[string]interface{}{"id": json.Number("-9007199254740993")},
+	}
+	for in, expected := range cases {
+		actual, err := InferTypes(p, in)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, actual, "Input: %s", in)
+	}
+
+	actual, err := InferTypes(p, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, actual)
+}
+
+func TestSchemaCoerce(t *testing.T) {
+	s := Schema{
+		"/null":   NullType,
+		"/bool":   BoolType,
+		"/num":    NumberType,
+		"/str":    StringType,
+		"/arr":    ArrayType,
+		"/obj":    ObjectType,
+		"/any":    AnyType,
+		"/a~1b/0": NumberType,
+	}
+	values := map[string]interface{}{
+		"/null":    "null",
+		"/bool":    "true",
+		"/num":     "8080",
+		"/str":     "123",
+		"/arr":     "[1]",
+		"/obj":     `{"a":"b"}`,
+		"/any":     "true",
+		"#/a~1b/0": "2",
+		"/other":   "false",
+	}
+	e := &Expander{Coerce: s.Coerce}
+	result, err := e.Expand(values)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"null":  nil,
+		"bool":  true,
+		"num":   8080.0,
+		"str":   "123",
+		"arr":   []interface{}{1.0},
+		"obj":   map[string]interface{}{"a": "b"},
+		"any":   "true",
+		"a/b":   map[string]interface{}{"0": 2.0},
+		"other": "false",
+	}, result)
+}
+
+func TestSchemaCoerceErrors(t *testing.T) {
+	s := Schema{
+		"/num":  NumberType,
+		"/bool": BoolType,
+		"/arr":  ArrayType,
+		"/null": NullType,
+	}
+	assertCoerceFails(t, s, "/num", "12abc", "Cannot coerce '12abc' to number at '/num'")
+	assertCoerceFails(t, s, "/num", "NaN", "Cannot coerce 'NaN' to number at '/num'")
+	assertCoerceFails(t, s, "/bool", "yes", "Cannot coerce 'yes' to bool at '/bool'")
+	assertCoerceFails(t, s, "/arr", `{"a":1}`, `Cannot coerce '{"a":1}' to array at '/arr'`)
+	assertCoerceFails(t, s, "/null", "nil", "Cannot coerce 'nil' to null at '/null'")
+	assertCoerceFails(t, s, "/num", true, "Cannot coerce bool to number at '/num'")
+
+	v, err := s.Coerce(MustConstruct("/num"), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, v)
+}
+
+func TestSchemaCoerceLargeInteger(t *testing.T) {
+	s := Schema{"/id": NumberType}
+	v, err := s.Coerce(MustConstruct("/id"), "9007199254740993")
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), v)
+
+	v, err = s.Coerce(MustConstruct("/id"), "0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.1, v)
+}
+
+func TestCoerceErrorStopsExpand(t *testing.T) {
+	s := Schema{"/num": NumberType}
+	e := &Expander{Coerce: s.Coerce}
+	_, err := e.Expand(map[string]interface{}{"/num": "many"})
+	assert.NotNil(t, err)
+}
+
+func TestTypeOf(t *testing.T) {
+	assert.Equal(t, NullType, TypeOf(nil))
+	assert.Equal(t, BoolType, TypeOf(false))
+	assert.Equal(t, NumberType, TypeOf(1.5))
+	assert.Equal(t, NumberType, TypeOf(json.Number("1")))
+	assert.Equal(t, NumberType, TypeOf(uint64(1)))
+	assert.Equal(t, StringType, TypeOf(""))
+	assert.Equal(t, ArrayType, TypeOf([]interface{}{}))
+	assert.Equal(t, ObjectType, TypeOf(map[string]interface{}{}))
+	assert.Equal(t, AnyType, TypeOf(struct{}{}))
+	assert.Equal(t, "object", ObjectType.String())
+	assert.Equal(t, "ValueType(42)", ValueType(42).String())
+}
+
+func TestTableRoundTripInferTypes(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(TableDoc), &doc)
+
+	var buf bytes.Buffer
+	tab := &Tabulator{Coerce: InferTypes}
+	assert.Nil(t, tab.Write(&buf, doc, MustConstruct("/rows")))
+	rows, err := tab.Read(&buf)
+	assert.Nil(t, err)
+
+	out, _ := json.Marshal(rows)
+	assert.Equal(t, `[{"loc":[1,2],"name":"first","tags":{"a":true}},`+
+		`{"loc":[3,4,5,6,7,8,9,10,11,12,13],"name":"second"},`+
+		`{"extra":null,"name":"with,comma"}]`, string(out))
+}
+
+func ExampleInferTypes() {
+	e := &Expander{Coerce: InferTypes}
+	doc, _ := e.Expand(map[string]interface{}{
+		"/port":  "8080",
+		"/debug": "true",
+		"/name":  "api",
+	})
+	out, _ := json.Marshal(doc)
+	fmt.Println(string(out))
+	// Output: {"debug":true,"name":"api","port":8080}
+}
+
+func assertCoerceFails(t *testing.T, s Schema, ptr string, val interface{}, msg string) {
+	_, err := s.Coerce(MustConstruct(ptr), val)
+	if assert.NotNil(t, err, "Pointer: %s", ptr) {
+		assert.Equal(t, msg, err.Error())
+	}
+}
@@ -31,12 +31,12 @@ func (c *Compactor) Flatten(document interface{}) map[string]interface{} {
 	var v visitor
 	if c.URIFragment {
 		v = func(path []string, val interface{}) {
-			p := Pointer{path}
+			p := Pointer{path: path}
 			res[p.URIFragmentIdent()] = val
 		}
 	} else {
 		v = func(path []string, val interface{}) {
-			p := Pointer{path}
+			p := Pointer{path: path}
 			res[p.String()] = val
 		}
 	}
@@ -57,7 +57,7 @@ List compacts the provided json document into a slice of PointerValues
 func (c *Compactor) List(document interface{}) []PointerValue {
 	res := make([]PointerValue, 0, 8)
 	c.visit(document, func(path []string, val interface{}) {
-		res = append(res, PointerValue{Pointer{path}, val})
+		res = append(res, PointerValue{Pointer{path: path}, val})
 	})
 	return res
 }
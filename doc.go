@@ -1,5 +1,12 @@
 /*
 Package jsonptr is an implmentation of RFC 6901 for go. It provides get, set,
-flatten/compact, and expansion functionality.
+delete, flatten/compact, and expansion functionality.
+
+New parses pointers leniently, accepting array indices like "01" and unknown
+"~" escapes. Use NewStrict, or Validate, to only accept pointers that follow
+RFC 6901 exactly.
+
+The jsonptr command, in cmd/jsonptr, provides the same functionality for shell
+scripts.
 */
 package jsonptr
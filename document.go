@@ -0,0 +1,251 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+/*
+Document is a JSON document that is safe for concurrent use by multiple
+goroutines. Values are copied on the way in and out, so callers never share
+maps or slices with the document and can use the results of Get and Snapshot,
+for example to marshal them, while other goroutines change the document.
+
+When the document is an object, changes below one of its keys only lock that
+key, so writers to different keys don't wait for each other, and readers only
+wait for writers to the key they read. Changes to the keys of the root object
+itself, to a root array, to values under a root level array, and patches lock
+the whole document.
+
+    doc := jsonptr.NewDocument(config)
+    go doc.Set(jsonptr.MustConstruct("/db/timeout"), 30.0)
+    go doc.Set(jsonptr.MustConstruct("/cache/size"), 100.0)
+    timeout, err := doc.Get(jsonptr.MustConstruct("/db/timeout"))
+*/
+type Document struct {
+	mu   sync.RWMutex // guards root, held exclusively to change the root or its keys
+	root interface{}
+
+	keysMu sync.Mutex               // guards keys
+	keys   map[string]*sync.RWMutex // guards the value under each key of a root object
+
+	watchMu sync.RWMutex // guards subs
+	subs    []*Subscription
+}
+
+// NewDocument returns a Document containing a copy of the given document. The
+// zero value is a Document containing null.
+func NewDocument(document interface{}) *Document {
+	return &Document{root: deepCopy(document)}
+}
+
+// Get returns a copy of the value at the location in the document.
+func (d *Document) Get(p *Pointer) (interface{}, error) {
+	if len(p.path) == 0 {
+		return d.Snapshot(), nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if l := d.subtree(p); l != nil {
+		l.RLock()
+		defer l.RUnlock()
+	}
+	val, err := p.Get(d.root)
+	if err != nil {
+		return nil, err
+	}
+	return deepCopy(val), nil
+}
+
+// Exists returns a boolean indicating whether the location exists in the
+// document.
+func (d *Document) Exists(p *Pointer) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if l := d.subtree(p); l != nil {
+		l.RLock()
+		defer l.RUnlock()
+	}
+	return p.Exists(d.root)
+}
+
+// Set sets a copy of val at the location in the document, like Pointer.Set.
+// Setting the root pointer replaces the whole document.
+func (d *Document) Set(p *Pointer, val interface{}) error {
+	return d.set(p, deepCopy(val), false)
+}
+
+// Force sets a copy of val at the location in the document, creating any
+// missing parents, like Pointer.Force. Forcing the root pointer replaces the
+// whole document.
+func (d *Document) Force(p *Pointer, val interface{}) error {
+	return d.set(p, deepCopy(val), true)
+}
+
+func (d *Document) set(p *Pointer, val interface{}, force bool) error {
+	if len(p.path) == 0 {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.watching() {
+			defer d.notify(Event{Op: "replace", Pointer: p, Old: d.root, New: deepCopy(val)})
+		}
+		d.root = val
+		d.resetKeys()
+		return nil
+	}
+	return d.change(p, func(sub *Pointer, node interface{}) error {
+		if !d.watching() {
+			return set(sub.path, node, val, force, sub.strict)
+		}
+		// the locks held by change are enough to read p from the root
+		ev := changeEvent(d.root, p, false)
+		if err := set(sub.path, node, val, force, sub.strict); err != nil {
+			return err
+		}
+		d.notify(ev.applied(d.root))
+		return nil
+	})
+}
+
+// Delete removes the location from the document, like Pointer.Delete.
+func (d *Document) Delete(p *Pointer) error {
+	return d.change(p, func(sub *Pointer, node interface{}) error {
+		if !d.watching() {
+			return sub.Delete(node)
+		}
+		ev := removeEvent(d.root, p)
+		if err := sub.Delete(node); err != nil {
+			return err
+		}
+		d.notify(ev)
+		return nil
+	})
+}
+
+// Patch applies the patch to the document. Like Patch.Apply, either the whole
+// patch is applied or, if any operation fails, none of it is.
+func (d *Document) Patch(patch Patch) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.watching() {
+		res, err := patch.Apply(d.root)
+		if err != nil {
+			return err
+		}
+		d.root = res
+		d.resetKeys()
+		return nil
+	}
+
+	res := deepCopy(d.root)
+	var events []Event
+	for i, op := range patch {
+		pending := patchEvents(res, op)
+		doc, err := op.apply(res)
+		if err != nil {
+			return &PatchError{i, op, err}
+		}
+		res = doc
+		for _, ev := range pending {
+			events = append(events, ev.applied(res))
+		}
+	}
+	d.root = res
+	d.resetKeys()
+	d.notify(events...)
+	return nil
+}
+
+// Snapshot returns a copy of the whole document. The copy is consistent: it
+// includes all of the changes made before it was taken, and none made after.
+func (d *Document) Snapshot() interface{} {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	obj, ok := d.root.(map[string]interface{})
+	if !ok {
+		return deepCopy(d.root)
+	}
+	// lock every key in a fixed order, so the copy doesn't see a change to
+	// one key without the changes made before it to another
+	keys := sortedKeys(obj)
+	for _, k := range keys {
+		l := d.lock(k)
+		l.RLock()
+		defer l.RUnlock()
+	}
+	return deepCopy(obj)
+}
+
+// MarshalJSON returns the JSON encoding of a snapshot of the document.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Snapshot())
+}
+
+// change runs fn to change the document at p. When p is below an object
+// under a key of a root object, fn is given that object and the rest of the
+// pointer, and only that key is locked. Otherwise, the whole document is
+// locked and fn is given the root and p.
+func (d *Document) change(p *Pointer, fn func(sub *Pointer, node interface{}) error) error {
+	d.mu.RLock()
+	if len(p.path) > 1 {
+		if obj, ok := d.root.(map[string]interface{}); ok {
+			if child, ok := obj[p.path[0]].(map[string]interface{}); ok {
+				defer d.mu.RUnlock()
+				l := d.lock(p.path[0])
+				l.Lock()
+				defer l.Unlock()
+				return fn(p.slice(1, len(p.path)), child)
+			}
+		}
+	}
+	d.mu.RUnlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := fn(p, d.root); err != nil {
+		return err
+	}
+	if len(p.path) == 1 {
+		d.keysMu.Lock()
+		delete(d.keys, p.path[0])
+		d.keysMu.Unlock()
+	}
+	return nil
+}
+
+// subtree returns the lock for the key of a root object that p is under, or
+// nil if p isn't under one. d.mu must be held.
+func (d *Document) subtree(p *Pointer) *sync.RWMutex {
+	if len(p.path) == 0 {
+		return nil
+	}
+	if obj, ok := d.root.(map[string]interface{}); ok {
+		if _, ok := obj[p.path[0]]; ok {
+			return d.lock(p.path[0])
+		}
+	}
+	return nil
+}
+
+// lock returns the lock for a key of a root object.
+func (d *Document) lock(key string) *sync.RWMutex {
+	d.keysMu.Lock()
+	defer d.keysMu.Unlock()
+	l, ok := d.keys[key]
+	if !ok {
+		if d.keys == nil {
+			d.keys = map[string]*sync.RWMutex{}
+		}
+		l = &sync.RWMutex{}
+		d.keys[key] = l
+	}
+	return l
+}
+
+// resetKeys drops the locks for keys of the root object. d.mu must be held
+// exclusively.
+func (d *Document) resetKeys() {
+	d.keysMu.Lock()
+	d.keys = nil
+	d.keysMu.Unlock()
+}
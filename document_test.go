@@ -0,0 +1,152 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func newTestDocument() *Document {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"db": {"host": "localhost", "ports": [5432]}, "name": "svc", "tags": ["a"]}`), &doc)
+	return NewDocument(doc)
+}
+
+func TestDocumentGetSet(t *testing.T) {
+	d := newTestDocument()
+	val, err := d.Get(MustConstruct("/db/host"))
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", val)
+
+	assert.Nil(t, d.Set(MustConstruct("/db/host"), "db.internal"))
+	assert.Nil(t, d.Set(MustConstruct("/db/ports/-"), 5433.0))
+	assert.Nil(t, d.Set(MustConstruct("/name"), "api"))
+	assert.Nil(t, d.Force(MustConstruct("/cache/size"), 10.0))
+	assert.Nil(t, d.Set(MustConstruct("/tags/0"), "b"))
+	assert.Error(t, d.Set(MustConstruct("/missing/key"), 1.0))
+	assert.Error(t, d.Set(MustConstruct("/name/key"), 1.0))
+
+	assert.Equal(t, map[string]interface{}{
+		"db":    map[string]interface{}{"host": "db.internal", "ports": []interface{}{5432.0, 5433.0}},
+		"name":  "api",
+		"tags":  []interface{}{"b"},
+		"cache": map[string]interface{}{"size": 10.0},
+	}, d.Snapshot())
+
+	_, err = d.Get(MustConstruct("/db/user"))
+	assert.Error(t, err)
+	assert.True(t, d.Exists(MustConstruct("/cache/size")))
+	assert.False(t, d.Exists(MustConstruct("/cache/ttl")))
+}
+
+func TestDocumentDelete(t *testing.T) {
+	d := newTestDocument()
+	assert.Nil(t, d.Delete(MustConstruct("/db/ports/0")))
+	assert.Nil(t, d.Delete(MustConstruct("/tags")))
+	assert.Error(t, d.Delete(MustConstruct("/tags")))
+	assert.Error(t, d.Delete(MustConstruct("")))
+	assert.Equal(t, map[string]interface{}{
+		"db":   map[string]interface{}{"host": "localhost", "ports": []interface{}{}},
+		"name": "svc",
+	}, d.Snapshot())
+}
+
+func TestDocumentCopies(t *testing.T) {
+	d := newTestDocument()
+	val := map[string]interface{}{"x": 1.0}
+	d.Set(MustConstruct("/obj"), val)
+	val["x"] = 2.0
+
+	got, _ := d.Get(MustConstruct("/obj"))
+	assert.Equal(t, map[string]interface{}{"x": 1.0}, got)
+	got.(map[string]interface{})["x"] = 3.0
+
+	snap := d.Snapshot().(map[string]interface{})
+	snap["obj"].(map[string]interface{})["x"] = 4.0
+	got, _ = d.Get(MustConstruct("/obj/x"))
+	assert.Equal(t, 1.0, got)
+}
+
+func TestDocumentRoot(t *testing.T) {
+	var d Document
+	val, err := d.Get(MustConstruct(""))
+	assert.Nil(t, err)
+	assert.Nil(t, val)
+
+	assert.Nil(t, d.Set(MustConstruct(""), []interface{}{1.0}))
+	assert.Nil(t, d.Set(MustConstruct("/0"), 2.0))
+	assert.Error(t, d.Delete(MustConstruct("/0")))
+	val, _ = d.Get(MustConstruct(""))
+	assert.Equal(t, []interface{}{2.0}, val)
+}
+
+func TestDocumentPatch(t *testing.T) {
+	d := newTestDocument()
+	err := d.Patch(Patch{
+		{Op: "replace", Path: "/name", Value: "api"},
+		{Op: "test", Path: "/db/host", Value: "elsewhere"},
+	})
+	assert.IsType(t, &PatchError{}, err)
+	val, _ := d.Get(MustConstruct("/name"))
+	assert.Equal(t, "svc", val)
+
+	assert.Nil(t, d.Patch(Patch{
+		{Op: "move", From: "/db", Path: "/database"},
+		{Op: "add", Path: "/database/user", Value: "admin"},
+	}))
+	assert.False(t, d.Exists(MustConstruct("/db")))
+	val, _ = d.Get(MustConstruct("/database/user"))
+	assert.Equal(t, "admin", val)
+}
+
+func TestDocumentConcurrent(t *testing.T) {
+	d := NewDocument(map[string]interface{}{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := MustConstruct("").Child(fmt.Sprint("k", i%4))
+			for j := 0; j < 200; j++ {
+				d.Force(key.Child("count"), float64(j))
+				d.Set(key.Child("list"), []interface{}{})
+				d.Set(key.Child("list").Append(), float64(j))
+				d.Get(key)
+				if j%50 == 0 {
+					json.Marshal(d)
+					d.Patch(Patch{{Op: "add", Path: "/p", Value: float64(j)}})
+					d.Delete(MustConstruct("/p"))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	snap := d.Snapshot().(map[string]interface{})
+	assert.Len(t, snap, 4)
+	for _, v := range snap {
+		assert.Equal(t, 199.0, v.(map[string]interface{})["count"])
+	}
+}
+
+func ExampleDocument() {
+	d := NewDocument(map[string]interface{}{"db": map[string]interface{}{"timeout": 10.0}})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		d.Set(MustConstruct("/db/timeout"), 30.0)
+	}()
+	go func() {
+		defer wg.Done()
+		d.Force(MustConstruct("/cache/size"), 100.0)
+	}()
+	wg.Wait()
+
+	out, _ := json.Marshal(d)
+	fmt.Println(string(out))
+	// Output: {"cache":{"size":100},"db":{"timeout":30}}
+}
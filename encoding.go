@@ -0,0 +1,100 @@
+package jsonptr
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pointers implement the encoding interfaces of the standard library, so
+// that they can be used as fields of structs that are stored as JSON, text or
+// in databases. The zero Pointer is the root pointer.
+
+// MarshalJSON marshals the pointer as a JSON string.
+func (p Pointer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON unmarshals a JSON string containing a pointer or URI
+// fragment identifier. A JSON null leaves the pointer unchanged.
+func (p *Pointer) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("Cannot unmarshal %s into a JSON Pointer", data)
+	}
+	return p.UnmarshalText([]byte(s))
+}
+
+// MarshalText returns the pointer as text, for encodings like YAML and for
+// flag.TextVar.
+func (p Pointer) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText parses a pointer or URI fragment identifier.
+func (p *Pointer) UnmarshalText(text []byte) error {
+	parsed, err := New(string(text))
+	if err != nil {
+		return err
+	}
+	*p = *parsed
+	return nil
+}
+
+/*
+Format implements fmt.Formatter. The %s and %v verbs print the pointer, and
+%q prints it quoted. With the # flag, as in %#s, the URI fragment identifier
+is printed instead. Widths and the - flag pad the output.
+
+    p := jsonptr.MustConstruct("/a b")
+    fmt.Printf("%s %#s %q", p, p, p)
+    // /a b #/a%20b "/a b"
+*/
+func (p Pointer) Format(f fmt.State, verb rune) {
+	s := p.String()
+	if f.Flag('#') {
+		s = p.URIFragmentIdent()
+	}
+	switch verb {
+	case 's', 'v':
+	case 'q':
+		s = strconv.Quote(s)
+	default:
+		fmt.Fprintf(f, "%%!%c(jsonptr.Pointer=%s)", verb, s)
+		return
+	}
+	if w, ok := f.Width(); ok && utf8.RuneCountInString(s) < w {
+		pad := strings.Repeat(" ", w-utf8.RuneCountInString(s))
+		if f.Flag('-') {
+			s += pad
+		} else {
+			s = pad + s
+		}
+	}
+	io.WriteString(f, s)
+}
+
+// Scan implements sql.Scanner, reading a pointer from a string or []byte
+// column. NULL is not a valid pointer, so nullable columns should be scanned
+// into a *Pointer, which database/sql sets to nil for NULL.
+func (p *Pointer) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return p.UnmarshalText([]byte(v))
+	case []byte:
+		return p.UnmarshalText(v)
+	}
+	return fmt.Errorf("Cannot scan %T into a JSON Pointer", src)
+}
+
+// Value implements driver.Valuer, storing the pointer as a string.
+func (p Pointer) Value() (driver.Value, error) {
+	return p.String(), nil
+}
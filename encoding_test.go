@@ -0,0 +1,107 @@
+package jsonptr
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+)
+
+type pointerConfig struct {
+	Watch  Pointer   `json:"watch"`
+	Ignore *Pointer  `json:"ignore"`
+	All    []Pointer `json:"all"`
+}
+
+func TestPointerJSON(t *testing.T) {
+	var c pointerConfig
+	err := json.Unmarshal([]byte(`{"watch":"/a~1b/0","ignore":"#/c%20d","all":["","/x"]}`), &c)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a/b", "0"}, c.Watch.Path())
+	assert.Equal(t, []string{"c d"}, c.Ignore.Path())
+	assert.Equal(t, 2, len(c.All))
+	assert.True(t, c.All[0].IsRoot())
+
+	out, err := json.Marshal(c)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"watch":"/a~1b/0","ignore":"/c d","all":["","/x"]}`, string(out))
+
+	// the zero Pointer is the root pointer
+	out, _ = json.Marshal(pointerConfig{})
+	assert.Equal(t, `{"watch":"","ignore":null,"all":null}`, string(out))
+}
+
+func TestPointerJSONErrors(t *testing.T) {
+	var c pointerConfig
+	assert.NotNil(t, json.Unmarshal([]byte(`{"watch":"a"}`), &c))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"watch":1}`), &c))
+
+	p := MustConstruct("/a")
+	assert.Nil(t, json.Unmarshal([]byte(`null`), p))
+	assert.Equal(t, "/a", p.String())
+}
+
+func TestPointerText(t *testing.T) {
+	p := MustConstruct("/a/b")
+	text, err := p.MarshalText()
+	assert.Nil(t, err)
+	assert.Equal(t, "/a/b", string(text))
+
+	var q Pointer
+	assert.Nil(t, q.UnmarshalText(text))
+	assert.True(t, q.Equal(p))
+	assert.NotNil(t, q.UnmarshalText([]byte("a/b")))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	var r Pointer
+	fs.TextVar(&r, "ptr", MustConstruct("/default"), "a pointer")
+	assert.Equal(t, "/default", r.String())
+	assert.Nil(t, fs.Parse([]string{"-ptr", "#/from~1flag"}))
+	assert.Equal(t, []string{"from/flag"}, r.Path())
+	assert.NotNil(t, fs.Parse([]string{"-ptr", "nope"}))
+}
+
+func TestPointerFormat(t *testing.T) {
+	p := MustConstruct("/a b/~0")
+	assert.Equal(t, "/a b/~0", fmt.Sprintf("%s", p))
+	assert.Equal(t, "/a b/~0", fmt.Sprintf("%v", p))
+	assert.Equal(t, "/a b/~0", fmt.Sprintf("%v", *p))
+	assert.Equal(t, "#/a%20b/~0", fmt.Sprintf("%#s", p))
+	assert.Equal(t, "#/a%20b/~0", fmt.Sprintf("%#v", p))
+	assert.Equal(t, `"/a b/~0"`, fmt.Sprintf("%q", p))
+	assert.Equal(t, `"#/a%20b/~0"`, fmt.Sprintf("%#q", p))
+	assert.Equal(t, "   /a b/~0|", fmt.Sprintf("%10s|", p))
+	assert.Equal(t, "/a b/~0   |", fmt.Sprintf("%-10s|", p))
+	assert.Equal(t, "%!d(jsonptr.Pointer=/a b/~0)", fmt.Sprintf("%d", p))
+	assert.Equal(t, "[  /x]", fmt.Sprintf("%v", []Pointer{{}, *MustConstruct("#"), *MustConstruct("/x")}))
+}
+
+func TestPointerSQL(t *testing.T) {
+	var _ sql.Scanner = &Pointer{}
+	var _ driver.Valuer = Pointer{}
+
+	p := MustConstruct("/a~1b")
+	v, err := p.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "/a~1b", v)
+
+	var q Pointer
+	assert.Nil(t, q.Scan("/a~1b"))
+	assert.True(t, q.Equal(p))
+	assert.Nil(t, q.Scan([]byte("#/c")))
+	assert.Equal(t, []string{"c"}, q.Path())
+	assert.NotNil(t, q.Scan(nil))
+	assert.NotNil(t, q.Scan(int64(1)))
+	assert.NotNil(t, q.Scan("c"))
+}
+
+func ExamplePointer_Format() {
+	p := MustConstruct("/a b")
+	fmt.Printf("%s %#s %q\n", p, p, p)
+	// Output: /a b #/a%20b "/a b"
+}
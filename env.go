@@ -0,0 +1,179 @@
+package jsonptr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/*
+EnvOverlay contains customizable options for how environment variables are
+applied onto a document, such as a service's default configuration.
+
+Only variables whose names start with Prefix are applied. The rest of each
+name is split into path segments on Separator, which defaults to "__" when
+empty, so with a Prefix of "APP_", APP_DB__HOSTS__0 sets /db/hosts/0. A
+doubled Separator stands for a literal Separator within a segment, so
+APP_MY____KEY sets /my__key.
+
+Each segment is matched against the keys of the document: an exact match is
+used first, then a case insensitive match. Segments that don't match an
+existing key are lowercased, unless PreserveCase is true.
+
+When Coerce is not nil, it is called with each value before it is applied.
+Use InferTypes to apply numbers and bools rather than strings.
+*/
+type EnvOverlay struct {
+	Prefix, Separator string
+	PreserveCase      bool
+	Coerce            Coercer
+}
+
+// EnvChange describes a change that an EnvOverlay makes to a document. When
+// Existed is false, the location did not exist before the change and Old is
+// nil.
+type EnvChange struct {
+	Variable string
+	Pointer  *Pointer
+	Old, New interface{}
+	Existed  bool
+}
+
+// Apply applies the variables in environ, which are "NAME=value" strings like
+// os.Environ returns, onto the document with Force. Variables are applied in
+// order of their names, and Apply returns the changes it made, omitting
+// variables whose value was already in the document.
+func (o *EnvOverlay) Apply(document interface{}, environ []string) ([]EnvChange, error) {
+	names, vars := o.variables(environ)
+	res := make([]EnvChange, 0, len(names))
+	for _, name := range names {
+		segments, err := o.segments(name)
+		if err != nil {
+			return nil, err
+		}
+		p := &Pointer{path: resolveSegments(document, segments, o.PreserveCase)}
+		var val interface{} = vars[name]
+		if o.Coerce != nil {
+			if val, err = o.Coerce(p, val); err != nil {
+				return nil, err
+			}
+		}
+		old, err := p.Get(document)
+		existed := err == nil
+		if existed && reflect.DeepEqual(old, val) {
+			continue
+		}
+		if err := p.Force(document, val); err != nil {
+			return nil, fmt.Errorf("Could not apply %s to '%s': %v", name, p, err)
+		}
+		res = append(res, EnvChange{name, p, old, val, existed})
+	}
+	return res, nil
+}
+
+// DryRun returns the changes that Apply would make to the document, without
+// changing it.
+func (o *EnvOverlay) DryRun(document interface{}, environ []string) ([]EnvChange, error) {
+	return o.Apply(deepCopy(document), environ)
+}
+
+// variables returns the sorted names and values of the variables that have
+// the prefix.
+func (o *EnvOverlay) variables(environ []string) ([]string, map[string]string) {
+	res := map[string]string{}
+	for _, kv := range environ {
+		eq := strings.Index(kv, "=")
+		if eq < 0 {
+			continue
+		}
+		name := kv[:eq]
+		if strings.HasPrefix(name, o.Prefix) && len(name) > len(o.Prefix) {
+			res[name] = kv[eq+1:]
+		}
+	}
+	names := make([]string, 0, len(res))
+	for name := range res {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, res
+}
+
+func (o *EnvOverlay) segments(name string) ([]string, error) {
+	sep := o.Separator
+	if sep == "" {
+		sep = "__"
+	}
+	rest := name[len(o.Prefix):]
+	segments := []string{}
+	var cur strings.Builder
+	for len(rest) > 0 {
+		if strings.HasPrefix(rest, sep+sep) {
+			cur.WriteString(sep)
+			rest = rest[2*len(sep):]
+		} else if strings.HasPrefix(rest, sep) {
+			segments = append(segments, cur.String())
+			cur.Reset()
+			rest = rest[len(sep):]
+		} else {
+			cur.WriteByte(rest[0])
+			rest = rest[1:]
+		}
+	}
+	segments = append(segments, cur.String())
+	for _, seg := range segments {
+		if seg == "" {
+			return nil, fmt.Errorf("Variable %s has an empty path segment", name)
+		}
+	}
+	return segments, nil
+}
+
+// resolveSegments matches segments to the keys of the document, see
+// EnvOverlay.
+func resolveSegments(document interface{}, segments []string, preserveCase bool) []string {
+	path := make([]string, len(segments))
+	node := document
+	for i, seg := range segments {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			path[i] = seg
+			if !preserveCase {
+				path[i] = strings.ToLower(seg)
+			}
+			if arr, ok := node.([]interface{}); ok {
+				if idx, ok := parseIndex(seg); ok && idx < len(arr) {
+					node = arr[idx]
+					continue
+				}
+			}
+			node = nil
+			continue
+		}
+		if child, ok := m[seg]; ok {
+			path[i], node = seg, child
+			continue
+		}
+		path[i], node = seg, nil
+		if !preserveCase {
+			path[i] = strings.ToLower(seg)
+		}
+		for _, k := range sortedKeys(m) {
+			if strings.EqualFold(k, seg) {
+				path[i], node = k, m[k]
+				break
+			}
+		}
+	}
+	return path
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
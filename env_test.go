@@ -0,0 +1,134 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+const EnvDefaults = `{
+  "db": {
+    "hosts": ["localhost"],
+    "port": 5432,
+    "maxConns": 10
+  },
+  "debug": false
+}`
+
+func getEnvDefaults() interface{} {
+	var doc interface{}
+	json.Unmarshal([]byte(EnvDefaults), &doc)
+	return doc
+}
+
+func TestEnvApply(t *testing.T) {
+	doc := getEnvDefaults()
+	o := &EnvOverlay{Prefix: "APP_", Separator: "__"}
+	changes, err := o.Apply(doc, []string{
+		"APP_DB__HOSTS__0=db1",
+		"APP_DB__HOSTS__1=db2",
+		"APP_DB__MAXCONNS=20",
+		"APP_NEW__KEY=value",
+		"APP_DEBUG=false",
+		"OTHER_VALUE=ignored",
+		"APP_=ignored",
+	})
+	assert.Nil(t, err)
+
+	out, _ := json.Marshal(doc)
+	assert.JSONEq(t, `{
+	  "db": {"hosts": ["db1", "db2"], "port": 5432, "maxConns": "20"},
+	  "debug": "false",
+	  "new": {"key": "value"}
+	}`, string(out))
+
+	if !assert.Equal(t, 5, len(changes)) {
+		return
+	}
+	assert.Equal(t, "APP_DB__HOSTS__0", changes[0].Variable)
+	assert.Equal(t, "/db/hosts/0", changes[0].Pointer.String())
+	assert.Equal(t, "localhost", changes[0].Old)
+	assert.Equal(t, "db1", changes[0].New)
+	assert.True(t, changes[0].Existed)
+
+	assert.Equal(t, "/db/hosts/1", changes[1].Pointer.String())
+	assert.False(t, changes[1].Existed)
+	assert.Equal(t, "/db/maxConns", changes[2].Pointer.String())
+	assert.Equal(t, "/debug", changes[3].Pointer.String())
+	assert.Equal(t, "/new/key", changes[4].Pointer.String())
+	assert.Nil(t, changes[4].Old)
+}
+
+func TestEnvApplyCoerce(t *testing.T) {
+	doc := getEnvDefaults()
+	o := &EnvOverlay{Prefix: "APP_", Coerce: InferTypes}
+	changes, err := o.Apply(doc, []string{
+		"APP_DB__PORT=5432",
+		"APP_DEBUG=true",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, true, GetBool(doc, "/debug"))
+}
+
+func TestEnvApplyEscaping(t *testing.T) {
+	doc := map[string]interface{}{}
+	o := &EnvOverlay{Prefix: "APP_", Separator: "_", PreserveCase: true}
+	_, err := o.Apply(doc, []string{
+		"APP_LOG_MAX__SIZE=10",
+		"APP_A____B=c",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"LOG":  map[string]interface{}{"MAX_SIZE": "10"},
+		"A__B": "c",
+	}, doc)
+
+	_, err = o.Apply(doc, []string{"APP_LOG_=10"})
+	assert.NotNil(t, err)
+}
+
+func TestEnvApplyConflict(t *testing.T) {
+	doc := getEnvDefaults()
+	o := &EnvOverlay{Prefix: "APP_"}
+	_, err := o.Apply(doc, []string{"APP_DEBUG__LEVEL=1"})
+	assert.NotNil(t, err)
+}
+
+func TestEnvDryRun(t *testing.T) {
+	doc := getEnvDefaults()
+	o := &EnvOverlay{Prefix: "APP_"}
+	changes, err := o.DryRun(doc, []string{"APP_DB__HOSTS__0=db1", "APP_DB__USER=admin"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(changes))
+
+	out, _ := json.Marshal(doc)
+	assert.JSONEq(t, EnvDefaults, string(out))
+}
+
+func TestApplyEnv(t *testing.T) {
+	os.Setenv("JSONPTR_TEST_DB__PORT", "6543")
+	defer os.Unsetenv("JSONPTR_TEST_DB__PORT")
+
+	doc := getEnvDefaults()
+	changes, err := ApplyEnv(doc, "JSONPTR_TEST_", "__")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, "6543", GetString(doc, "/db/port"))
+}
+
+func ExampleEnvOverlay_DryRun() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"db":{"host":"localhost","port":5432}}`), &doc)
+
+	o := &EnvOverlay{Prefix: "APP_", Separator: "__", Coerce: InferTypes}
+	changes, _ := o.DryRun(doc, []string{"APP_DB__HOST=db.internal", "APP_DB__PORT=5432", "APP_DB__SSL=true"})
+	for _, c := range changes {
+		fmt.Printf("%s: %v -> %v\n", c.Pointer, c.Old, c.New)
+	}
+	// Output:
+	// /db/host: localhost -> db.internal
+	// /db/ssl: <nil> -> true
+}
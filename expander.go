@@ -2,58 +2,237 @@ package jsonptr
 
 import (
 	"fmt"
-	"strconv"
+	"sort"
+	"strings"
 )
 
 // Expander contains customizable options for how a document can be expanded.
 //
 // When DetectArrays is true, then resulting nodes with only "0" or positive
 // integer keys are made into []interface{} types, rather than
-// map[string]interface.
+// map[string]interface. Keys must be array indices as defined by RFC 6901, so
+// "007" or "+1" keep their node a map. Arrays determines whether nodes with
+// gaps in their indices are detected, and MaxIndex, when positive, is the
+// largest index a detected array may have. When MaxIndex is zero, a node is
+// only detected as an array if its largest index is less than 4 times its
+// number of keys, so that a few large indices can't allocate a huge array.
+//
+// Conflicts determines what happens when one key is a prefix of another, such
+// as "/a" and "/a/b", since both can't be set in the same document. By
+// default, Expand returns a *ConflictError.
+//
+// When Coerce is not nil, it is called with each value before the value is
+// added to the document. See InferTypes and Schema for built in Coercers.
 type Expander struct {
 	DetectArrays bool
+	Arrays       ArrayPolicy
+	MaxIndex     int
+	Conflicts    ConflictPolicy
+	Coerce       Coercer
+}
+
+// ArrayPolicy determines which nodes an Expander detects as arrays.
+type ArrayPolicy int
+
+const (
+	// ArraysFillGaps detects nodes with gaps in their indices as arrays, and
+	// fills the gaps with nil.
+	ArraysFillGaps ArrayPolicy = iota
+	// ArraysContiguous only detects nodes with every index from 0 to their
+	// largest index as arrays.
+	ArraysContiguous
+)
+
+// ConflictPolicy determines how an Expander handles keys that conflict with
+// each other.
+type ConflictPolicy int
+
+const (
+	// ConflictFail returns a *ConflictError for the first conflict found.
+	ConflictFail ConflictPolicy = iota
+	// ConflictDeeperWins keeps the value of the longer key, and drops the
+	// value of the shorter key.
+	ConflictDeeperWins
+	// ConflictShallowerWins keeps the value of the shorter key, and drops the
+	// values of any longer keys.
+	ConflictShallowerWins
+)
+
+// ConflictError is returned by Expand when the Shallow key is a prefix of the
+// Deep key, or when both keys refer to the same location. Keys that refer to
+// the same location always conflict, regardless of the ConflictPolicy.
+type ConflictError struct {
+	Shallow, Deep string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Cannot expand conflicting keys '%s' and '%s'", e.Shallow, e.Deep)
 }
 
 // Expand expands a map with keys containing json pointers into a full json.Marshal-able document
 func (e *Expander) Expand(values map[string]interface{}) (interface{}, error) {
-	result := map[string]interface{}{}
-	for k, v := range values {
-		if k == "" {
-			return nil, fmt.Errorf("Cannot expand when the key is \"\", set directly instead")
-		}
-		if err := Force(result, k, v); err != nil {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	x := e.begin()
+	for _, k := range keys {
+		if err := x.add(k, values[k]); err != nil {
 			return nil, err
 		}
 	}
-	if e.DetectArrays {
-		return detectArrays(result), nil
+	return x.finish(), nil
+}
+
+// expansion holds the state of a document that is being expanded one value
+// at a time. leaves maps each location that has been set to the key that set
+// it, and owners maps each location that was created as a parent to the
+// first key that created it. Locations are normalized with Pointer.String.
+type expansion struct {
+	e      *Expander
+	result map[string]interface{}
+	leaves map[string]string
+	owners map[string]string
+}
+
+func (e *Expander) begin() *expansion {
+	return &expansion{e, map[string]interface{}{}, map[string]string{}, map[string]string{}}
+}
+
+func (x *expansion) add(key string, val interface{}) error {
+	if key == "" {
+		return fmt.Errorf("Cannot expand when the key is \"\", set directly instead")
+	}
+	p, err := New(key)
+	if err != nil {
+		return err
+	}
+	if len(p.path) == 0 {
+		return fmt.Errorf("Cannot expand when the key is \"%s\", set directly instead", key)
+	}
+	if x.e.Coerce != nil {
+		if val, err = x.e.Coerce(p, val); err != nil {
+			return err
+		}
+	}
+	loc := p.String()
+
+	if shallow, ok := x.leaves[loc]; ok {
+		return &ConflictError{shallow, key}
+	}
+	for i := 1; i < len(p.path); i++ {
+		parent := Pointer{path: p.path[:i]}
+		shallow, ok := x.leaves[parent.String()]
+		if !ok {
+			continue
+		}
+		switch x.e.Conflicts {
+		case ConflictDeeperWins:
+			delete(x.leaves, parent.String())
+			if err := parent.Force(x.result, map[string]interface{}{}); err != nil {
+				return err
+			}
+		case ConflictShallowerWins:
+			return nil
+		default:
+			return &ConflictError{shallow, key}
+		}
+	}
+	if deep, ok := x.owners[loc]; ok {
+		switch x.e.Conflicts {
+		case ConflictDeeperWins:
+			return nil
+		case ConflictShallowerWins:
+			x.forget(loc)
+		default:
+			return &ConflictError{key, deep}
+		}
+	}
+
+	if err := p.Force(x.result, val); err != nil {
+		return err
+	}
+	x.leaves[loc] = key
+	for i := 1; i < len(p.path); i++ {
+		parent := Pointer{path: p.path[:i]}
+		if _, ok := x.owners[parent.String()]; !ok {
+			x.owners[parent.String()] = key
+		}
 	}
-	return result, nil
+	return nil
 }
 
-func detectArrays(target map[string]interface{}) interface{} {
-	allInts := true
-	slice := make([]interface{}, len(target))
-	for k, v := range target {
-		val := v
-		if m, ok := v.(map[string]interface{}); ok {
-			val = detectArrays(m)
-			target[k] = val
+// forget removes all locations below loc, so that loc can be replaced.
+func (x *expansion) forget(loc string) {
+	delete(x.owners, loc)
+	for k := range x.owners {
+		if strings.HasPrefix(k, loc+"/") {
+			delete(x.owners, k)
+		}
+	}
+	for k := range x.leaves {
+		if strings.HasPrefix(k, loc+"/") {
+			delete(x.leaves, k)
+		}
+	}
+}
+
+func (x *expansion) finish() interface{} {
+	if x.e.DetectArrays {
+		return x.e.detectArrays(x.result)
+	}
+	return x.result
+}
+
+func (e *Expander) detectArrays(node interface{}) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = e.detectArrays(child)
 		}
-		i, err := strconv.Atoi(k)
-		if i > len(slice)-1 {
-			sl := make([]interface{}, i+1)
-			copy(sl, slice)
-			slice = sl
+		if arr, ok := e.asArray(v); ok {
+			return arr
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = e.detectArrays(child)
+		}
+	}
+	return node
+}
+
+// maxSparseness is how many elements a detected array may have for each key
+// of its node, the rest being gaps filled with nil, when the Expander has no
+// MaxIndex.
+const maxSparseness = 4
+
+func (e *Expander) asArray(target map[string]interface{}) ([]interface{}, bool) {
+	if len(target) == 0 {
+		return nil, false
+	}
+	max := -1
+	for k := range target {
+		i, ok := parseIndex(k)
+		if !ok || (e.MaxIndex > 0 && i > e.MaxIndex) {
+			return nil, false
 		}
-		if err != nil || i < 0 {
-			allInts = false
-		} else {
-			slice[i] = val
+		if i > max {
+			max = i
 		}
 	}
-	if allInts {
-		return slice
+	if e.Arrays == ArraysContiguous && max != len(target)-1 {
+		return nil, false
+	}
+	if e.MaxIndex <= 0 && max >= maxSparseness*len(target) {
+		// mostly gaps, so it's better left as a map
+		return nil, false
+	}
+	slice := make([]interface{}, max+1)
+	for k, v := range target {
+		i, _ := parseIndex(k)
+		slice[i] = v
 	}
-	return target
+	return slice, true
 }
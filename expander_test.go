@@ -81,6 +81,180 @@ func TestExpandDetectArrays(t *testing.T) {
 	assert.Equal(t, testValues["/arr/5"], arr[5])
 }
 
+func TestExpandDetectArraysStrictIndices(t *testing.T) {
+	e := &Expander{DetectArrays: true}
+	result, err := e.Expand(map[string]interface{}{
+		"/zero/007":                      "a",
+		"/plus/+1":                       "b",
+		"/minus/-1":                      "c",
+		"/empty/":                        "d",
+		"/large/99999999999999999999999": "e",
+	})
+	assert.Nil(t, err)
+	m := result.(map[string]interface{})
+	for _, k := range []string{"zero", "plus", "minus", "empty", "large"} {
+		_, ok := m[k].(map[string]interface{})
+		assert.True(t, ok, "Expected %s to remain a map", k)
+	}
+}
+
+func TestExpandDetectArraysContiguous(t *testing.T) {
+	e := &Expander{DetectArrays: true, Arrays: ArraysContiguous}
+	result, err := e.Expand(testValues)
+	assert.Nil(t, err)
+	m := result.(map[string]interface{})
+	_, ok := m["arr"].(map[string]interface{})
+	assert.True(t, ok, "Expected arr with a gap to remain a map")
+
+	result, err = e.Expand(map[string]interface{}{"/a/1": "b", "/a/0": "a"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{"a", "b"}}, result)
+}
+
+func TestExpandDetectArraysMaxIndex(t *testing.T) {
+	e := &Expander{DetectArrays: true, MaxIndex: 4}
+	result, err := e.Expand(testValues)
+	assert.Nil(t, err)
+	m := result.(map[string]interface{})
+	_, ok := m["arr"].(map[string]interface{})
+	assert.True(t, ok, "Expected arr with index 5 to remain a map")
+
+	e.MaxIndex = 5
+	result, err = e.Expand(testValues)
+	assert.Nil(t, err)
+	m = result.(map[string]interface{})
+	_, ok = m["arr"].([]interface{})
+	assert.True(t, ok, "Expected arr to be detected")
+}
+
+func TestExpandDetectArraysSparse(t *testing.T) {
+	e := &Expander{DetectArrays: true}
+	result, err := e.Expand(map[string]interface{}{
+		"/huge/9223372036854775807": "a",
+		"/far/100000000000":         "b",
+		"/gaps/0":                   "c",
+		"/gaps/8":                   "d",
+		"/near/0":                   "e",
+		"/near/3":                   "f",
+	})
+	assert.Nil(t, err)
+	m := result.(map[string]interface{})
+	for _, k := range []string{"huge", "far", "gaps"} {
+		_, ok := m[k].(map[string]interface{})
+		assert.True(t, ok, "Expected %s to remain a map", k)
+	}
+	assert.Equal(t, []interface{}{"e", nil, nil, "f"}, m["near"])
+
+	// an explicit MaxIndex replaces the sparseness limit
+	e.MaxIndex = 100
+	result, err = e.Expand(map[string]interface{}{"/a/0": "a", "/a/8": "b", "/b/101": "c"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": []interface{}{"a", nil, nil, nil, nil, nil, nil, nil, "b"},
+		"b": map[string]interface{}{"101": "c"},
+	}, result)
+}
+
+func TestExpandDetectArraysRecursive(t *testing.T) {
+	e := &Expander{DetectArrays: true}
+	result, err := e.Expand(map[string]interface{}{
+		"/0/0/0": "a",
+		"/0/1":   []interface{}{map[string]interface{}{"0": "b"}},
+		"/1":     map[string]interface{}{},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{
+			[]interface{}{"a"},
+			[]interface{}{[]interface{}{"b"}},
+		},
+		map[string]interface{}{},
+	}, result)
+}
+
+func TestExpandConflicts(t *testing.T) {
+	values := map[string]interface{}{
+		"/a":     1,
+		"/a/b":   2,
+		"/a/c/d": 3,
+		"/e":     4,
+	}
+
+	for i := 0; i < 10; i++ {
+		_, err := Expand(values)
+		conflict, ok := err.(*ConflictError)
+		if !assert.True(t, ok, "Expected a *ConflictError, got %v", err) {
+			return
+		}
+		assert.Equal(t, "/a", conflict.Shallow)
+		assert.Equal(t, "/a/b", conflict.Deep)
+	}
+
+	e := &Expander{Conflicts: ConflictDeeperWins}
+	result, err := e.Expand(values)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": 2,
+			"c": map[string]interface{}{"d": 3},
+		},
+		"e": 4,
+	}, result)
+
+	e = &Expander{Conflicts: ConflictShallowerWins}
+	result, err = e.Expand(values)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1, "e": 4}, result)
+}
+
+func TestExpandConflictsAnyOrder(t *testing.T) {
+	deeper := &Expander{Conflicts: ConflictDeeperWins}
+	shallower := &Expander{Conflicts: ConflictShallowerWins}
+	orders := [][]string{
+		{"/a", "/a/b", "/a/c/d"},
+		{"/a/b", "/a", "/a/c/d"},
+		{"/a/c/d", "/a/b", "/a"},
+	}
+	for _, keys := range orders {
+		x := deeper.begin()
+		for i, k := range keys {
+			assert.Nil(t, x.add(k, i))
+		}
+		res := x.finish().(map[string]interface{})
+		a := res["a"].(map[string]interface{})
+		assert.Equal(t, 2, len(a), "Order: %v", keys)
+
+		x = shallower.begin()
+		for i, k := range keys {
+			assert.Nil(t, x.add(k, i))
+		}
+		res = x.finish().(map[string]interface{})
+		_, isMap := res["a"].(map[string]interface{})
+		assert.False(t, isMap, "Order: %v", keys)
+	}
+}
+
+func TestExpandDuplicateLocation(t *testing.T) {
+	e := &Expander{Conflicts: ConflictDeeperWins}
+	_, err := e.Expand(map[string]interface{}{
+		"/a b":    1,
+		"#/a%20b": 2,
+	})
+	conflict, ok := err.(*ConflictError)
+	if assert.True(t, ok) {
+		assert.Equal(t, "#/a%20b", conflict.Shallow)
+		assert.Equal(t, "/a b", conflict.Deep)
+		assert.Equal(t, "Cannot expand conflicting keys '#/a%20b' and '/a b'", conflict.Error())
+	}
+}
+
+func TestExpandRootKey(t *testing.T) {
+	_, err := Expand(map[string]interface{}{"": 1})
+	assert.NotNil(t, err)
+	_, err = Expand(map[string]interface{}{"#": 1})
+	assert.NotNil(t, err)
+}
+
 func BenchmarkExpandDefaults(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		Expand(testValues)
@@ -0,0 +1,216 @@
+package jsonptr
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+FormDecoder contains customizable options for how HTML form submissions are
+expanded into documents. Field names are json pointers, like "/address/city".
+
+A "-" segment appends a new element to an array for every value of the field,
+so a field named "/tags/-" submitted with the values "a" and "b" becomes
+{"tags": ["a", "b"]}. Appended elements are placed after any elements set by
+fields with explicit indices. Fields without a "-" segment use their first
+value, like url.Values.Get.
+
+When Brackets is true, field names may also use bracket syntax, where
+"address[city]" is "/address/city", "tags[]" is "/tags/-", and a plain name
+like "email" is "/email". Fields whose names refer to the same location, like
+"email" and "/email", are a *ConflictError.
+
+The options of the embedded Expander are used to expand the fields, except
+that arrays are always detected. Since anyone can submit a form, MaxIndex is
+1000 unless it is set, and a field with a larger index in its name, or with
+more appended values than fit below it, is an error rather than making a huge
+array.
+*/
+type FormDecoder struct {
+	Expander
+	Brackets bool
+}
+
+// Decode expands url.Values, such as http.Request.Form, into a full
+// json.Marshal-able document.
+func (d *FormDecoder) Decode(values url.Values) (interface{}, error) {
+	fields := make(map[string][]interface{}, len(values))
+	for name, vals := range values {
+		for _, v := range vals {
+			fields[name] = append(fields[name], v)
+		}
+	}
+	return d.decode(fields)
+}
+
+// DecodeMultipart expands a multipart form, such as
+// http.Request.MultipartForm, into a full json.Marshal-able document. Files
+// are added to the document as *multipart.FileHeader values.
+func (d *FormDecoder) DecodeMultipart(form *multipart.Form) (interface{}, error) {
+	fields := make(map[string][]interface{}, len(form.Value)+len(form.File))
+	for name, vals := range form.Value {
+		for _, v := range vals {
+			fields[name] = append(fields[name], v)
+		}
+	}
+	for name, files := range form.File {
+		for _, f := range files {
+			fields[name] = append(fields[name], f)
+		}
+	}
+	return d.decode(fields)
+}
+
+func (d *FormDecoder) decode(fields map[string][]interface{}) (interface{}, error) {
+	names := make([]string, 0, len(fields))
+	paths := make(map[string][]string, len(fields))
+	for name := range fields {
+		path, err := d.fieldPath(name)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		paths[name] = path
+	}
+	sort.Strings(names)
+
+	x := d.Expander
+	x.DetectArrays = true
+	if x.MaxIndex <= 0 {
+		x.MaxIndex = defaultFormMaxIndex
+	}
+
+	// appending starts after the largest explicit index of each parent
+	next := map[string]int{}
+	for _, name := range names {
+		path := paths[name]
+		if err := checkIndices(name, path, x.MaxIndex); err != nil {
+			return nil, err
+		}
+		for i, seg := range path {
+			if seg == "-" {
+				break
+			}
+			idx, ok := parseIndex(seg)
+			parent := Pointer{path: path[:i]}
+			if ok && idx >= next[parent.String()] {
+				next[parent.String()] = idx + 1
+			}
+		}
+	}
+
+	// fields like "a" and "/a" can name the same location
+	values := map[string]interface{}{}
+	owners := map[string]string{}
+	set := func(name string, path []string, v interface{}) error {
+		loc := (&Pointer{path: path}).String()
+		if prev, ok := owners[loc]; ok {
+			return &ConflictError{prev, name}
+		}
+		owners[loc] = name
+		values[loc] = v
+		return nil
+	}
+	for _, name := range names {
+		path := paths[name]
+		vals := fields[name]
+		if len(vals) == 0 {
+			continue
+		}
+		if !hasAppend(path) {
+			if err := set(name, path, vals[0]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, v := range vals {
+			appended := appendIndices(path, next)
+			if err := checkIndices(name, appended, x.MaxIndex); err != nil {
+				return nil, err
+			}
+			if err := set(name, appended, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return x.Expand(values)
+}
+
+// defaultFormMaxIndex is the largest index a form field may have when the
+// Expander doesn't set MaxIndex.
+const defaultFormMaxIndex = 1000
+
+// checkIndices returns an error if any index in the path of the named field
+// is larger than max.
+func checkIndices(name string, path []string, max int) error {
+	for _, seg := range path {
+		if idx, ok := parseIndex(seg); ok && idx > max {
+			return fmt.Errorf("Form field '%s' has index %d, larger than the maximum of %d", name, idx, max)
+		}
+	}
+	return nil
+}
+
+func (d *FormDecoder) fieldPath(name string) ([]string, error) {
+	if strings.HasPrefix(name, "/") || strings.HasPrefix(name, "#") || !d.Brackets {
+		p, err := New(name)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid form field name '%s': %v", name, err)
+		}
+		return p.path, nil
+	}
+	return parseBrackets(name)
+}
+
+// parseBrackets splits a bracket style name like "a[b][]" into path
+// segments, where "[]" is the "-" segment.
+func parseBrackets(name string) ([]string, error) {
+	open := strings.Index(name, "[")
+	if open < 0 {
+		return []string{name}, nil
+	}
+	path := []string{name[:open]}
+	rest := name[open:]
+	for len(rest) > 0 {
+		end := strings.Index(rest, "]")
+		if rest[0] != '[' || end < 0 {
+			return nil, fmt.Errorf("Invalid form field name '%s'", name)
+		}
+		seg := rest[1:end]
+		if seg == "" {
+			seg = "-"
+		}
+		path = append(path, seg)
+		rest = rest[end+1:]
+	}
+	return path, nil
+}
+
+func hasAppend(path []string) bool {
+	for _, seg := range path {
+		if seg == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// appendIndices replaces each "-" segment of the path with the next index of
+// its parent.
+func appendIndices(path []string, next map[string]int) []string {
+	res := make([]string, len(path))
+	copy(res, path)
+	for i, seg := range res {
+		if seg != "-" {
+			continue
+		}
+		parent := (&Pointer{path: res[:i]}).String()
+		res[i] = strconv.Itoa(next[parent])
+		next[parent]++
+	}
+	return res
+}
@@ -0,0 +1,160 @@
+package jsonptr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"net/url"
+	"testing"
+)
+
+func TestDecodeForm(t *testing.T) {
+	values := url.Values{
+		"/address/city": {"Agawam", "ignored"},
+		"/address/zip":  {"01001"},
+		"/tags/-":       {"a", "b", "c"},
+		"/items/0/name": {"first"},
+		"/items/-/name": {"second", "third"},
+	}
+	doc, err := DecodeForm(values)
+	assert.Nil(t, err)
+	out, _ := json.Marshal(doc)
+	assert.JSONEq(t, `{
+	  "address": {"city": "Agawam", "zip": "01001"},
+	  "tags": ["a", "b", "c"],
+	  "items": [{"name": "first"}, {"name": "second"}, {"name": "third"}]
+	}`, string(out))
+}
+
+func TestDecodeFormNestedAppend(t *testing.T) {
+	d := &FormDecoder{}
+	doc, err := d.Decode(url.Values{"/a/-/b/-": {"x", "y"}})
+	assert.Nil(t, err)
+	out, _ := json.Marshal(doc)
+	assert.Equal(t, `{"a":[{"b":["x"]},{"b":["y"]}]}`, string(out))
+}
+
+func TestDecodeFormBrackets(t *testing.T) {
+	d := &FormDecoder{Brackets: true}
+	doc, err := d.Decode(url.Values{
+		"email":         {"a@example.com"},
+		"address[city]": {"Agawam"},
+		"tags[]":        {"a", "b"},
+		"loc[1]":        {"42.07"},
+		"/pointer/name": {"ok"},
+	})
+	assert.Nil(t, err)
+	out, _ := json.Marshal(doc)
+	assert.JSONEq(t, `{
+	  "email": "a@example.com",
+	  "address": {"city": "Agawam"},
+	  "tags": ["a", "b"],
+	  "loc": [null, "42.07"],
+	  "pointer": {"name": "ok"}
+	}`, string(out))
+
+	_, err = d.Decode(url.Values{"address[city": {"x"}})
+	assert.NotNil(t, err)
+	_, err = d.Decode(url.Values{"address[city]x": {"x"}})
+	assert.NotNil(t, err)
+}
+
+func TestDecodeFormErrors(t *testing.T) {
+	_, err := DecodeForm(url.Values{"email": {"a@example.com"}})
+	assert.NotNil(t, err)
+	_, err = DecodeForm(url.Values{"/a": {"1"}, "/a/b": {"2"}})
+	assert.NotNil(t, err)
+}
+
+func TestDecodeFormLargeIndex(t *testing.T) {
+	_, err := DecodeForm(url.Values{"/a/9223372036854775807": {"x"}})
+	assert.NotNil(t, err)
+	_, err = DecodeForm(url.Values{"/a/1001": {"x"}, "/a/-": {"y"}})
+	assert.NotNil(t, err)
+
+	doc, err := DecodeForm(url.Values{"/a/1000": {"x"}})
+	assert.Nil(t, err)
+	arr := doc.(map[string]interface{})["a"].([]interface{})
+	assert.Equal(t, 1001, len(arr))
+	assert.Equal(t, "x", arr[1000])
+
+	d := &FormDecoder{Expander: Expander{MaxIndex: 5000}}
+	_, err = d.Decode(url.Values{"/a/1001": {"x"}})
+	assert.Nil(t, err)
+}
+
+func TestDecodeFormSameLocation(t *testing.T) {
+	d := &FormDecoder{Brackets: true}
+	_, err := d.Decode(url.Values{"a": {"x"}, "/a": {"y"}})
+	assert.Equal(t, &ConflictError{"/a", "a"}, err)
+
+	_, err = d.Decode(url.Values{"tags[0]": {"x"}, "/tags/0": {"y"}})
+	assert.Equal(t, &ConflictError{"/tags/0", "tags[0]"}, err)
+
+	doc, err := d.Decode(url.Values{"tags[]": {"x"}, "/tags/-": {"y"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"tags": []interface{}{"y", "x"}}, doc)
+}
+
+func TestDecodeFormTooManyAppends(t *testing.T) {
+	tags := make([]string, 1005)
+	for i := range tags {
+		tags[i] = "t"
+	}
+	_, err := DecodeForm(url.Values{"/tags/-": tags})
+	assert.EqualError(t, err, "Form field '/tags/-' has index 1001, larger than the maximum of 1000")
+
+	doc, err := DecodeForm(url.Values{"/tags/-": tags[:1001]})
+	assert.Nil(t, err)
+	assert.Equal(t, 1001, len(doc.(map[string]interface{})["tags"].([]interface{})))
+}
+
+func TestDecodeFormCoerce(t *testing.T) {
+	d := &FormDecoder{Expander: Expander{Coerce: InferTypes}}
+	doc, err := d.Decode(url.Values{"/qty/-": {"1", "2"}, "/gift": {"true"}})
+	assert.Nil(t, err)
+	out, _ := json.Marshal(doc)
+	assert.Equal(t, `{"gift":true,"qty":[1,2]}`, string(out))
+}
+
+func TestDecodeMultipart(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("/title", "report")
+	w.WriteField("/tags/-", "a")
+	w.WriteField("/tags/-", "b")
+	fw, _ := w.CreateFormFile("/attachments/-", "notes.txt")
+	fw.Write([]byte("hello"))
+	w.Close()
+
+	r := multipart.NewReader(&body, w.Boundary())
+	form, err := r.ReadForm(1 << 20)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer form.RemoveAll()
+
+	d := &FormDecoder{}
+	doc, err := d.DecodeMultipart(form)
+	assert.Nil(t, err)
+	assert.Equal(t, "report", GetString(doc, "/title"))
+	assert.Equal(t, "b", GetString(doc, "/tags/1"))
+
+	file, err := Get(doc, "/attachments/0")
+	assert.Nil(t, err)
+	fh, ok := file.(*multipart.FileHeader)
+	if assert.True(t, ok) {
+		assert.Equal(t, "notes.txt", fh.Filename)
+	}
+}
+
+func ExampleFormDecoder_Decode() {
+	values, _ := url.ParseQuery("address[city]=Agawam&tags[]=a&tags[]=b")
+	d := &FormDecoder{Brackets: true}
+	doc, _ := d.Decode(values)
+	out, _ := json.Marshal(doc)
+	fmt.Println(string(out))
+	// Output: {"address":{"city":"Agawam"},"tags":["a","b"]}
+}
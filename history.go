@@ -0,0 +1,240 @@
+package jsonptr
+
+import (
+	"fmt"
+)
+
+// Step is one entry in a History: a single change, or the changes made in a
+// group, along with the operations that undo them.
+type Step struct {
+	Name    string
+	Forward Patch
+	Inverse Patch
+}
+
+/*
+History changes a document in place and records every change, so that it can
+be undone and redone. Each change is recorded as JSON Patch operations, along
+with the inverse operations that undo it.
+
+Changes made between Begin and End are grouped into a single named step, which
+is undone and redone as a whole. When limit is positive, only that many steps
+are kept, and the oldest steps are forgotten first.
+
+The document must only be changed through the History, or undoing and redoing
+may fail.
+
+    h := jsonptr.NewHistory(doc, 100)
+    h.Begin("rename")
+    h.Set(jsonptr.MustConstruct("/first"), "Jo")
+    h.Set(jsonptr.MustConstruct("/last"), "Smith")
+    h.End()
+    h.Undo() // both names are restored
+*/
+type History struct {
+	document interface{}
+	limit    int
+	undo     []Step
+	redo     []Step
+	depth    int
+}
+
+// NewHistory returns a History that changes the given document, keeping at
+// most limit steps, or every step if limit is 0.
+func NewHistory(document interface{}, limit int) *History {
+	return &History{document: document, limit: limit}
+}
+
+// Document returns the current root of the document. It is only different
+// from the document the History was created with if the root was replaced.
+func (h *History) Document() interface{} {
+	return h.document
+}
+
+// Set sets val at the location, like Pointer.Set. Setting the root pointer
+// replaces the whole document.
+func (h *History) Set(p *Pointer, val interface{}) error {
+	ops, err := setOps(h.document, p, val, false)
+	if err != nil {
+		return err
+	}
+	return h.record(ops)
+}
+
+// Force sets val at the location, creating any missing parents, like
+// Pointer.Force. Forcing the root pointer replaces the whole document.
+func (h *History) Force(p *Pointer, val interface{}) error {
+	ops, err := setOps(h.document, p, val, true)
+	if err != nil {
+		return err
+	}
+	return h.record(ops)
+}
+
+// Delete removes the location from the document, like Pointer.Delete.
+func (h *History) Delete(p *Pointer) error {
+	path, err := resolvePath(h.document, p)
+	if err != nil {
+		return err
+	}
+	if len(path) == 0 {
+		return fmt.Errorf("Cannot delete root object")
+	}
+	return h.record(Patch{{Op: "remove", Path: (&Pointer{path: path}).String()}})
+}
+
+// Begin starts a group of changes, which is recorded as a single step with
+// the given name when End is called. Groups can be nested, in which case the
+// changes are part of the outermost group.
+func (h *History) Begin(name string) {
+	if h.depth == 0 {
+		h.undo = append(h.undo, Step{Name: name})
+	}
+	h.depth++
+}
+
+// End ends the group started by the last call to Begin. A group without any
+// changes is not recorded.
+func (h *History) End() {
+	if h.depth > 1 {
+		h.depth--
+		return
+	}
+	h.endGroup()
+}
+
+// endGroup ends any open group, dropping it if it is empty.
+func (h *History) endGroup() {
+	if h.depth > 0 {
+		if len(h.undo[len(h.undo)-1].Forward) == 0 {
+			h.undo = h.undo[:len(h.undo)-1]
+		} else {
+			h.trim()
+		}
+	}
+	h.depth = 0
+}
+
+// Undo undoes the last step and returns it, or returns an error if there is
+// nothing to undo. An open group is ended first.
+func (h *History) Undo() (Step, error) {
+	h.endGroup()
+	if len(h.undo) == 0 {
+		return Step{}, fmt.Errorf("Nothing to undo")
+	}
+	step := h.undo[len(h.undo)-1]
+	if err := h.apply(step.Inverse); err != nil {
+		return step, err
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, step)
+	return step, nil
+}
+
+// Redo redoes the last undone step and returns it, or returns an error if
+// there is nothing to redo. An open group is ended first.
+func (h *History) Redo() (Step, error) {
+	h.endGroup()
+	if len(h.redo) == 0 {
+		return Step{}, fmt.Errorf("Nothing to redo")
+	}
+	step := h.redo[len(h.redo)-1]
+	if err := h.apply(step.Forward); err != nil {
+		return step, err
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, step)
+	return step, nil
+}
+
+// CanUndo returns true if there is a step to undo.
+func (h *History) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+// CanRedo returns true if there is a step to redo.
+func (h *History) CanRedo() bool {
+	return len(h.redo) > 0
+}
+
+// Steps returns the steps that can be undone, oldest first.
+func (h *History) Steps() []Step {
+	return append([]Step(nil), h.undo...)
+}
+
+// record applies operations that are known to be valid, and adds them to
+// the current step.
+func (h *History) record(ops Patch) error {
+	inverse := make(Patch, len(ops))
+	for i, op := range ops {
+		inv, err := invert(h.document, op)
+		if err != nil {
+			return err
+		}
+		if h.document, err = op.apply(h.document); err != nil {
+			return err
+		}
+		inverse[len(ops)-1-i] = inv
+	}
+
+	h.redo = nil
+	if h.depth == 0 {
+		h.undo = append(h.undo, Step{})
+	}
+	step := &h.undo[len(h.undo)-1]
+	step.Forward = append(step.Forward, ops...)
+	step.Inverse = append(inverse, step.Inverse...)
+	if h.depth == 0 {
+		h.trim()
+	}
+	return nil
+}
+
+// trim forgets the oldest steps beyond the limit. It is only called once a
+// step has changes, so an empty group doesn't push out older steps.
+func (h *History) trim() {
+	if h.limit > 0 && len(h.undo) > h.limit {
+		h.undo = append(h.undo[:0:0], h.undo[len(h.undo)-h.limit:]...)
+	}
+}
+
+func (h *History) apply(patch Patch) error {
+	res, err := patch.apply(h.document)
+	if err != nil {
+		return err
+	}
+	h.document = res
+	return nil
+}
+
+// invert returns the operation that undoes an "add", "remove" or "replace"
+// operation, before it is applied to the document. Array indices in the
+// operation must be numbers.
+func invert(document interface{}, op Operation) (Operation, error) {
+	path, err := New(op.Path)
+	if err != nil {
+		return Operation{}, err
+	}
+	switch op.Op {
+	case "add":
+		if path.IsRoot() {
+			return Operation{Op: "replace", Path: op.Path, Value: document}, nil
+		}
+		if parent, ok := getOrNil(path.Parent(), document).(map[string]interface{}); ok {
+			if old, ok := parent[path.Last()]; ok {
+				return Operation{Op: "replace", Path: op.Path, Value: old}, nil
+			}
+		}
+		return Operation{Op: "remove", Path: op.Path}, nil
+	case "replace", "remove":
+		old, err := getValue(document, path.path)
+		if err != nil {
+			return Operation{}, err
+		}
+		if op.Op == "remove" {
+			return Operation{Op: "add", Path: op.Path, Value: old}, nil
+		}
+		return Operation{Op: "replace", Path: op.Path, Value: old}, nil
+	}
+	return Operation{}, fmt.Errorf("Cannot invert '%s' operation", op.Op)
+}
@@ -0,0 +1,158 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func historyDoc() interface{} {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"first": "Jo", "tags": ["a", "b"], "meta": {"v": 1}}`), &doc)
+	return doc
+}
+
+func TestHistoryUndoRedo(t *testing.T) {
+	doc := historyDoc()
+	h := NewHistory(doc, 0)
+	assert.False(t, h.CanUndo())
+
+	assert.Nil(t, h.Set(MustConstruct("/first"), "Joe"))
+	assert.Nil(t, h.Set(MustConstruct("/tags/-"), "c"))
+	assert.Nil(t, h.Delete(MustConstruct("/tags/0")))
+	assert.Nil(t, h.Force(MustConstruct("/a/b/c"), true))
+	assert.Nil(t, h.Set(MustConstruct("/meta"), "flat"))
+	assert.Error(t, h.Set(MustConstruct("/missing/x"), 1.0))
+	assert.Len(t, h.Steps(), 5)
+
+	changed := deepCopy(doc)
+	for i := 0; i < 5; i++ {
+		_, err := h.Undo()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, historyDoc(), doc)
+	_, err := h.Undo()
+	assert.Error(t, err)
+
+	for h.CanRedo() {
+		_, err := h.Redo()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, changed, doc)
+	_, err = h.Redo()
+	assert.Error(t, err)
+}
+
+func TestHistoryInverse(t *testing.T) {
+	h := NewHistory(historyDoc(), 0)
+	h.Force(MustConstruct("/tags/3"), "d")
+	h.Delete(MustConstruct("/meta"))
+	steps := h.Steps()
+
+	out, _ := json.Marshal(steps[0].Forward)
+	assert.Equal(t, `[{"op":"add","path":"/tags/2","value":null},{"op":"add","path":"/tags/3","value":"d"}]`, string(out))
+	out, _ = json.Marshal(steps[0].Inverse)
+	assert.Equal(t, `[{"op":"remove","path":"/tags/3"},{"op":"remove","path":"/tags/2"}]`, string(out))
+	out, _ = json.Marshal(steps[1].Inverse)
+	assert.Equal(t, `[{"op":"add","path":"/meta","value":{"v":1}}]`, string(out))
+}
+
+func TestHistoryGroups(t *testing.T) {
+	doc := historyDoc()
+	h := NewHistory(doc, 0)
+	h.Begin("rename")
+	h.Set(MustConstruct("/first"), "Jane")
+	h.Begin("nested")
+	h.Force(MustConstruct("/last"), "Doe")
+	h.End()
+	h.End()
+	h.Begin("empty")
+	h.End()
+	h.Set(MustConstruct("/tags/0"), "z")
+
+	steps := h.Steps()
+	assert.Len(t, steps, 2)
+	assert.Equal(t, "rename", steps[0].Name)
+	assert.Equal(t, "", steps[1].Name)
+
+	h.Undo()
+	step, err := h.Undo()
+	assert.Nil(t, err)
+	assert.Equal(t, "rename", step.Name)
+	assert.Equal(t, historyDoc(), doc)
+
+	h.Begin("open")
+	h.Set(MustConstruct("/first"), "Ann")
+	assert.False(t, h.CanRedo(), "a new change should clear redo")
+	step, _ = h.Undo()
+	assert.Equal(t, "open", step.Name)
+	assert.Equal(t, historyDoc(), doc)
+}
+
+func TestHistoryLimit(t *testing.T) {
+	doc := map[string]interface{}{"n": 0.0}
+	h := NewHistory(doc, 3)
+	for i := 1; i <= 5; i++ {
+		h.Set(MustConstruct("/n"), float64(i))
+	}
+	assert.Len(t, h.Steps(), 3)
+	for h.CanUndo() {
+		h.Undo()
+	}
+	assert.Equal(t, 2.0, doc["n"])
+}
+
+func TestHistoryLimitEmptyGroup(t *testing.T) {
+	doc := map[string]interface{}{"n": 0.0}
+	h := NewHistory(doc, 2)
+	h.Set(MustConstruct("/n"), 1.0)
+	h.Set(MustConstruct("/n"), 2.0)
+	h.Begin("nothing")
+	h.End()
+	assert.Len(t, h.Steps(), 2)
+
+	h.Begin("group")
+	h.Set(MustConstruct("/n"), 3.0)
+	h.End()
+	assert.Len(t, h.Steps(), 2)
+	for h.CanUndo() {
+		h.Undo()
+	}
+	assert.Equal(t, 1.0, doc["n"])
+}
+
+func TestHistoryRoot(t *testing.T) {
+	h := NewHistory([]interface{}{1.0}, 0)
+	h.Set(MustConstruct("/-"), 2.0)
+	h.Delete(MustConstruct("/0"))
+	assert.Equal(t, []interface{}{2.0}, h.Document())
+	h.Set(MustConstruct(""), "scalar")
+	assert.Equal(t, "scalar", h.Document())
+	assert.Error(t, h.Delete(MustConstruct("")))
+
+	for h.CanUndo() {
+		h.Undo()
+	}
+	assert.Equal(t, []interface{}{1.0}, h.Document())
+}
+
+func ExampleHistory() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"first": "Jo", "last": "Smith"}`), &doc)
+
+	h := NewHistory(doc, 100)
+	h.Begin("rename")
+	h.Set(MustConstruct("/first"), "Jane")
+	h.Set(MustConstruct("/last"), "Doe")
+	h.End()
+	out, _ := json.Marshal(h.Document())
+	fmt.Println(string(out))
+
+	step, _ := h.Undo()
+	out, _ = json.Marshal(h.Document())
+	fmt.Println("undo", step.Name, string(out))
+	// Output:
+	// {"first":"Jane","last":"Doe"}
+	// undo rename {"first":"Jo","last":"Smith"}
+}
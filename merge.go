@@ -0,0 +1,145 @@
+package jsonptr
+
+import (
+	"strconv"
+)
+
+// Conflict is a location that was changed differently in both documents of a
+// three-way merge. Base, Ours and Theirs are the values in each document, and
+// InBase, InOurs and InTheirs report whether the location exists in each, to
+// tell a removed value apart from a null one.
+type Conflict struct {
+	Pointer  *Pointer
+	Base     interface{}
+	Ours     interface{}
+	Theirs   interface{}
+	InBase   bool
+	InOurs   bool
+	InTheirs bool
+}
+
+// Resolver decides the merged value of a conflict. It returns the value and
+// whether the location should exist in the merged document, or false for ok
+// to leave the conflict unresolved.
+type Resolver func(c Conflict) (val interface{}, exists, ok bool)
+
+// Ours is a Resolver that resolves every conflict with our change.
+func Ours(c Conflict) (interface{}, bool, bool) {
+	return c.Ours, c.InOurs, true
+}
+
+// Theirs is a Resolver that resolves every conflict with their change.
+func Theirs(c Conflict) (interface{}, bool, bool) {
+	return c.Theirs, c.InTheirs, true
+}
+
+// Merger contains options for three-way merges. Resolve, if set, is called for
+// each conflict, in pointer order.
+type Merger struct {
+	Resolve Resolver
+}
+
+/*
+Merge3 merges the changes made from base to ours and from base to theirs,
+and returns the merged document along with the conflicts it couldn't resolve.
+None of the documents are changed.
+
+A location changed in only one document, or changed the same way in both,
+takes the changed value. When both documents change an object, their changes
+are merged key by key, and when both change an array without changing its
+length, element by element. Any other location changed differently in both
+documents is a conflict. Conflicts that the Resolver doesn't resolve keep our
+value in the merged document.
+
+    // Given base is {"a": 1, "b": 1}, ours is {"a": 2, "b": 1} and theirs is {"a": 3, "b": 2}
+    res, conflicts := (&jsonptr.Merger{}).Merge3(base, ours, theirs)
+    // res is {"a": 2, "b": 2}
+    // conflicts[0].Pointer.String() == "/a"
+*/
+func (m *Merger) Merge3(base, ours, theirs interface{}) (interface{}, []Conflict) {
+	var conflicts []Conflict
+	res := m.merge([]string{}, mergeValue{base, true}, mergeValue{ours, true}, mergeValue{theirs, true}, &conflicts)
+	return deepCopy(res.val), conflicts
+}
+
+// Merge3 merges the changes made from base to ours and from base to theirs,
+// and returns the merged document along with the conflicts, like
+// Merger.Merge3 without a Resolver.
+func Merge3(base, ours, theirs interface{}) (interface{}, []Conflict) {
+	return (&Merger{}).Merge3(base, ours, theirs)
+}
+
+// mergeValue is the value at a location in one of the documents of a merge.
+type mergeValue struct {
+	val    interface{}
+	exists bool
+}
+
+func (v mergeValue) equal(other mergeValue) bool {
+	return v.exists == other.exists && (!v.exists || equalValues(v.val, other.val))
+}
+
+func (m *Merger) merge(path []string, base, ours, theirs mergeValue, conflicts *[]Conflict) mergeValue {
+	switch {
+	case ours.equal(theirs), theirs.equal(base):
+		return ours
+	case ours.equal(base):
+		return theirs
+	}
+
+	o, oObj := ours.val.(map[string]interface{})
+	t, tObj := theirs.val.(map[string]interface{})
+	b, bObj := base.val.(map[string]interface{})
+	if oObj && tObj && (bObj || !base.exists) {
+		res := map[string]interface{}{}
+		for _, k := range sortedKeys(unionKeys(b, o, t)) {
+			v := m.merge(childpath(path, k), member(b, k), member(o, k), member(t, k), conflicts)
+			if v.exists {
+				res[k] = v.val
+			}
+		}
+		return mergeValue{res, true}
+	}
+
+	oa, oArr := ours.val.([]interface{})
+	ta, tArr := theirs.val.([]interface{})
+	ba, bArr := base.val.([]interface{})
+	if oArr && tArr && bArr && len(oa) == len(ba) && len(ta) == len(ba) {
+		res := make([]interface{}, 0, len(ba))
+		for i := range ba {
+			v := m.merge(childpath(path, strconv.Itoa(i)), mergeValue{ba[i], true}, mergeValue{oa[i], true}, mergeValue{ta[i], true}, conflicts)
+			if v.exists {
+				res = append(res, v.val)
+			}
+		}
+		return mergeValue{res, true}
+	}
+
+	c := Conflict{
+		Pointer: compile(path, false),
+		Base:    base.val, Ours: ours.val, Theirs: theirs.val,
+		InBase: base.exists, InOurs: ours.exists, InTheirs: theirs.exists,
+	}
+	if m.Resolve != nil {
+		if val, exists, ok := m.Resolve(c); ok {
+			return mergeValue{val, exists}
+		}
+	}
+	*conflicts = append(*conflicts, c)
+	return ours
+}
+
+func member(obj map[string]interface{}, key string) mergeValue {
+	val, ok := obj[key]
+	return mergeValue{val, ok}
+}
+
+func unionKeys(objs ...map[string]interface{}) map[string]interface{} {
+	keys := map[string]interface{}{}
+	for _, obj := range objs {
+		for k := range obj {
+			keys[k] = nil
+		}
+	}
+	return keys
+}
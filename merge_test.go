@@ -0,0 +1,110 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func parseDocs(docs ...string) []interface{} {
+	res := make([]interface{}, len(docs))
+	for i, doc := range docs {
+		if err := json.Unmarshal([]byte(doc), &res[i]); err != nil {
+			panic(err)
+		}
+	}
+	return res
+}
+
+func assertMerges(t *testing.T, m *Merger, base, ours, theirs, expected string, conflicts ...string) {
+	docs := parseDocs(base, ours, theirs, expected)
+	res, found := m.Merge3(docs[0], docs[1], docs[2])
+	assert.Equal(t, docs[3], res, "merging %s, %s and %s", base, ours, theirs)
+	var ptrs []string
+	for _, c := range found {
+		ptrs = append(ptrs, c.Pointer.String())
+	}
+	assert.Equal(t, conflicts, ptrs, "conflicts merging %s, %s and %s", base, ours, theirs)
+}
+
+func TestMerge3(t *testing.T) {
+	m := &Merger{}
+	assertMerges(t, m, `{"a":1,"b":1}`, `{"a":2,"b":1}`, `{"a":1,"b":2}`, `{"a":2,"b":2}`)
+	assertMerges(t, m, `{"a":1}`, `{"a":1,"b":1}`, `{"a":1,"c":1}`, `{"a":1,"b":1,"c":1}`)
+	assertMerges(t, m, `{"a":1,"b":1}`, `{"b":1}`, `{"a":1}`, `{}`)
+	assertMerges(t, m, `{"a":1}`, `{"a":2}`, `{"a":2}`, `{"a":2}`)
+	assertMerges(t, m, `{"a":{"x":1,"y":1}}`, `{"a":{"x":2,"y":1}}`, `{"a":{"x":1,"y":2}}`, `{"a":{"x":2,"y":2}}`)
+	assertMerges(t, m, `{}`, `{"a":{"x":1}}`, `{"a":{"y":1}}`, `{"a":{"x":1,"y":1}}`)
+	assertMerges(t, m, `[1,2,3]`, `[0,2,3]`, `[1,2,4]`, `[0,2,4]`)
+	assertMerges(t, m, `{"n":1}`, `{"n":1.0}`, `{"n":2}`, `{"n":2}`)
+	assertMerges(t, m, `1`, `2`, `1`, `2`)
+}
+
+func TestMerge3Conflicts(t *testing.T) {
+	m := &Merger{}
+	assertMerges(t, m, `{"a":1,"b":1}`, `{"a":2,"b":1}`, `{"a":3,"b":2}`, `{"a":2,"b":2}`, "/a")
+	assertMerges(t, m, `{"a":{"x":1}}`, `{}`, `{"a":{"x":2}}`, `{}`, "/a")
+	assertMerges(t, m, `{"a":[1]}`, `{"a":[1,2]}`, `{"a":[1,3]}`, `{"a":[1,2]}`, "/a")
+	assertMerges(t, m, `{"a":null}`, `{"a":1}`, `{"a":"x"}`, `{"a":1}`, "/a")
+	assertMerges(t, m, `{"l":[{"k":1},{"k":1}]}`, `{"l":[{"k":2},{"k":3}]}`, `{"l":[{"k":4},{"k":3}]}`, `{"l":[{"k":2},{"k":3}]}`, "/l/0/k")
+	assertMerges(t, m, `1`, `2`, `3`, `2`, "")
+
+	docs := parseDocs(`{"a":1}`, `{}`, `{"a":null}`)
+	_, conflicts := Merge3(docs[0], docs[1], docs[2])
+	assert.Equal(t, []Conflict{{
+		Pointer: MustConstruct("/a"),
+		Base:    1.0, Ours: nil, Theirs: nil,
+		InBase: true, InOurs: false, InTheirs: true,
+	}}, conflicts)
+}
+
+func TestMerge3Resolvers(t *testing.T) {
+	base, ours, theirs := `{"a":1,"b":{"c":1},"d":1}`, `{"a":2,"d":2}`, `{"a":3,"b":{"c":2},"d":1}`
+	assertMerges(t, &Merger{Resolve: Ours}, base, ours, theirs, `{"a":2,"d":2}`)
+	assertMerges(t, &Merger{Resolve: Theirs}, base, ours, theirs, `{"a":3,"b":{"c":2},"d":2}`)
+
+	var seen []string
+	custom := &Merger{Resolve: func(c Conflict) (interface{}, bool, bool) {
+		seen = append(seen, c.Pointer.String())
+		if a, ok := c.Ours.(float64); ok {
+			return a + c.Theirs.(float64), true, true
+		}
+		return nil, false, false
+	}}
+	assertMerges(t, custom, base, ours, theirs, `{"a":5,"d":2}`, "/b")
+	assert.Equal(t, []string{"/a", "/b"}, seen)
+
+	remove := &Merger{Resolve: func(c Conflict) (interface{}, bool, bool) { return nil, false, true }}
+	assertMerges(t, remove, `[1,2]`, `[3,2]`, `[4,2]`, `[2]`)
+}
+
+func TestMerge3DoesNotChangeInputs(t *testing.T) {
+	docs := parseDocs(`{"a":{"x":1}}`, `{"a":{"x":1},"b":[1]}`, `{"a":{"x":2}}`)
+	res, _ := Merge3(docs[0], docs[1], docs[2])
+	res.(map[string]interface{})["b"].([]interface{})[0] = 5.0
+	res.(map[string]interface{})["a"].(map[string]interface{})["x"] = 5.0
+	assert.Equal(t, parseDocs(`{"a":{"x":1}}`, `{"a":{"x":1},"b":[1]}`, `{"a":{"x":2}}`), docs)
+}
+
+func ExampleMerger_Merge3() {
+	docs := parseDocs(
+		`{"theme": "dark", "font": "mono", "size": 12}`,
+		`{"theme": "light", "font": "mono", "size": 14}`,
+		`{"theme": "blue", "font": "serif", "size": 12}`,
+	)
+	res, conflicts := (&Merger{}).Merge3(docs[0], docs[1], docs[2])
+	for _, c := range conflicts {
+		fmt.Println("conflict at", c.Pointer, c.Base, c.Ours, c.Theirs)
+	}
+	out, _ := json.Marshal(res)
+	fmt.Println(string(out))
+
+	res, _ = (&Merger{Resolve: Theirs}).Merge3(docs[0], docs[1], docs[2])
+	out, _ = json.Marshal(res)
+	fmt.Println(string(out))
+	// Output:
+	// conflict at /theme dark light blue
+	// {"font":"serif","size":14,"theme":"light"}
+	// {"font":"serif","size":14,"theme":"blue"}
+}
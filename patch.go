@@ -0,0 +1,407 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Operation is a single RFC 6902 JSON Patch operation. Op is one of "add",
+// "remove", "replace", "move", "copy" or "test". Path and From are json
+// pointers.
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Patch is an RFC 6902 JSON Patch, a sequence of operations that are applied
+// in order.
+type Patch []Operation
+
+// PatchError is returned when an operation of a Patch can't be applied.
+// Index is the position of the operation in the Patch.
+type PatchError struct {
+	Index int
+	Op    Operation
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("Could not apply operation %d (%s '%s'): %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+// MarshalJSON marshals the operation, including the value of "add",
+// "replace" and "test" operations even when it is nil.
+func (op Operation) MarshalJSON() ([]byte, error) {
+	switch op.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			Op    string      `json:"op"`
+			Path  string      `json:"path"`
+			Value interface{} `json:"value"`
+		}{op.Op, op.Path, op.Value})
+	case "move", "copy":
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			From string `json:"from"`
+			Path string `json:"path"`
+		}{op.Op, op.From, op.Path})
+	}
+	return json.Marshal(struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+	}{op.Op, op.Path})
+}
+
+/*
+Apply applies the patch to a copy of the document, and returns the patched
+copy. The document itself is not changed, so if any operation fails, Apply
+returns a *PatchError and none of the patch is applied.
+
+    // Given doc is unmarshalled from {"a": [1, 2]}
+    res, err := jsonptr.Patch{
+        {Op: "add", Path: "/a/0", Value: 0},
+        {Op: "remove", Path: "/a/2"},
+    }.Apply(doc)
+    // res is {"a": [0, 1]}
+*/
+func (p Patch) Apply(document interface{}) (interface{}, error) {
+	return p.apply(deepCopy(document))
+}
+
+// apply applies the patch to the document in place, returning the new root.
+func (p Patch) apply(document interface{}) (interface{}, error) {
+	for i, op := range p {
+		doc, err := op.apply(document)
+		if err != nil {
+			return nil, &PatchError{i, op, err}
+		}
+		document = doc
+	}
+	return document, nil
+}
+
+func (op Operation) apply(document interface{}) (interface{}, error) {
+	path, err := New(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add":
+		return addValue(document, path.path, deepCopy(op.Value))
+	case "remove":
+		doc, _, err := removeValue(document, path.path)
+		return doc, err
+	case "replace":
+		return replaceValue(document, path.path, deepCopy(op.Value))
+	case "move", "copy":
+		from, err := New(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			val, err := getValue(document, from.path)
+			if err != nil {
+				return nil, err
+			}
+			return addValue(document, path.path, deepCopy(val))
+		}
+		if hasPathPrefix(path.path, from.path) {
+			if len(path.path) == len(from.path) {
+				return document, nil
+			}
+			return nil, fmt.Errorf("Cannot move '%s' into itself", op.From)
+		}
+		doc, val, err := removeValue(document, from.path)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path.path, val)
+	case "test":
+		val, err := getValue(document, path.path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalValues(val, op.Value) {
+			return nil, fmt.Errorf("Test failed, value is %v", val)
+		}
+		return document, nil
+	}
+	return nil, fmt.Errorf("Unknown operation '%s'", op.Op)
+}
+
+// getValue returns the value at the path, requiring array indices to be
+// valid RFC 6901 indices.
+func getValue(document interface{}, path []string) (interface{}, error) {
+	node := document
+	for _, seg := range path {
+		switch v := node.(type) {
+		case map[string]interface{}:
+			n, ok := v[seg]
+			if !ok {
+				return nil, fmt.Errorf("Map had no key when evaluating path segment '%s'", seg)
+			}
+			node = n
+		case []interface{}:
+			i, err := patchIndex(seg, len(v)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = v[i]
+		default:
+			return nil, fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", node, seg)
+		}
+	}
+	return node, nil
+}
+
+// patchIndex parses an array index, and checks that it is no larger than max.
+func patchIndex(seg string, max int) (int, error) {
+	i, ok := parseIndex(seg)
+	if !ok {
+		return 0, fmt.Errorf("Could not index when evaluating path segment '%s'", seg)
+	}
+	if i > max {
+		return 0, fmt.Errorf("Slice index %d is out of range (max=%d)", i, max)
+	}
+	return i, nil
+}
+
+// replaceParent sets the parent of the last segment of the path to a new
+// array, returning the new root.
+func replaceParent(document interface{}, path []string, arr []interface{}) (interface{}, error) {
+	parent := path[:len(path)-1]
+	if len(parent) == 0 {
+		return arr, nil
+	}
+	return document, set(parent, document, arr, false, true)
+}
+
+func addValue(document interface{}, path []string, val interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return val, nil
+	}
+	parent, err := getValue(document, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	seg := path[len(path)-1]
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		v[seg] = val
+		return document, nil
+	case []interface{}:
+		i := len(v)
+		if seg != "-" {
+			if i, err = patchIndex(seg, len(v)); err != nil {
+				return nil, err
+			}
+		}
+		arr := make([]interface{}, 0, len(v)+1)
+		arr = append(append(append(arr, v[:i]...), val), v[i:]...)
+		return replaceParent(document, path, arr)
+	}
+	return nil, fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", parent, seg)
+}
+
+func removeValue(document interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("Cannot remove root object")
+	}
+	parent, err := getValue(document, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	seg := path[len(path)-1]
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		val, ok := v[seg]
+		if !ok {
+			return nil, nil, fmt.Errorf("Map had no key when evaluating path segment '%s'", seg)
+		}
+		delete(v, seg)
+		return document, val, nil
+	case []interface{}:
+		i, err := patchIndex(seg, len(v)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		val := v[i]
+		arr := make([]interface{}, 0, len(v)-1)
+		arr = append(append(arr, v[:i]...), v[i+1:]...)
+		doc, err := replaceParent(document, path, arr)
+		return doc, val, err
+	}
+	return nil, nil, fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", parent, seg)
+}
+
+func replaceValue(document interface{}, path []string, val interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return val, nil
+	}
+	parent, err := getValue(document, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	seg := path[len(path)-1]
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := v[seg]; !ok {
+			return nil, fmt.Errorf("Map had no key when evaluating path segment '%s'", seg)
+		}
+		v[seg] = val
+		return document, nil
+	case []interface{}:
+		i, err := patchIndex(seg, len(v)-1)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = val
+		return document, nil
+	}
+	return nil, fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", parent, seg)
+}
+
+/*
+Diff returns a Patch that changes the from document into the to document.
+Objects are compared key by key, in sorted order. Arrays are compared element
+by element, so inserting into the middle of an array produces replacements
+for every element after the insertion.
+
+    // Given a is {"a": 1, "b": [1]} and b is {"b": [1, 2], "c": 3}
+    p := jsonptr.Diff(a, b)
+    // p is [{"op":"remove","path":"/a"},{"op":"add","path":"/b/1","value":2},
+    //       {"op":"add","path":"/c","value":3}]
+*/
+func Diff(from, to interface{}) Patch {
+	p := Patch{}
+	diff(&p, []string{}, from, to)
+	return p
+}
+
+func diff(p *Patch, path []string, from, to interface{}) {
+	if equalValues(from, to) {
+		return
+	}
+	switch a := from.(type) {
+	case map[string]interface{}:
+		b, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(a)+len(b))
+		for k := range a {
+			keys = append(keys, k)
+		}
+		for k := range b {
+			if _, ok := a[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			av, inA := a[k]
+			bv, inB := b[k]
+			child := childpath(path, k)
+			if !inB {
+				*p = append(*p, Operation{Op: "remove", Path: (&Pointer{path: child}).String()})
+			} else if !inA {
+				*p = append(*p, Operation{Op: "add", Path: (&Pointer{path: child}).String(), Value: deepCopy(bv)})
+			} else {
+				diff(p, child, av, bv)
+			}
+		}
+		return
+	case []interface{}:
+		b, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		common := len(a)
+		if len(b) < common {
+			common = len(b)
+		}
+		for i := 0; i < common; i++ {
+			diff(p, childpath(path, strconv.Itoa(i)), a[i], b[i])
+		}
+		for i := common; i < len(b); i++ {
+			*p = append(*p, Operation{Op: "add", Path: (&Pointer{path: childpath(path, strconv.Itoa(i))}).String(), Value: deepCopy(b[i])})
+		}
+		for i := len(a) - 1; i >= common; i-- {
+			*p = append(*p, Operation{Op: "remove", Path: (&Pointer{path: childpath(path, strconv.Itoa(i))}).String()})
+		}
+		return
+	}
+	*p = append(*p, Operation{Op: "replace", Path: (&Pointer{path: path}).String(), Value: deepCopy(to)})
+}
+
+// equalValues reports whether two documents are equal. Numbers are equal if
+// they have the same value, whatever their types, so json.Number("1"),
+// float64(1) and int(1) are all equal.
+func equalValues(a, b interface{}) bool {
+	if ar, ok := numberRat(a); ok {
+		br, ok := numberRat(b)
+		return ok && ar.Cmp(br) == 0
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			other, ok := bv[k]
+			if !ok || !equalValues(v, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equalValues(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+/*
+MergePatch applies an RFC 7386 JSON Merge Patch to a copy of the document,
+and returns the result. Objects in the patch are merged into the document
+recursively, nil values in the patch remove keys from the document, and all
+other values replace the value in the document.
+
+    // Given doc is {"a": 1, "b": {"c": 2}} and patch is {"a": null, "b": {"d": 3}}
+    res := jsonptr.MergePatch(doc, patch)
+    // res is {"b": {"c": 2, "d": 3}}
+*/
+func MergePatch(document, patch interface{}) interface{} {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return deepCopy(patch)
+	}
+	res := map[string]interface{}{}
+	if dm, ok := document.(map[string]interface{}); ok {
+		for k, v := range dm {
+			res[k] = deepCopy(v)
+		}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(res, k)
+			continue
+		}
+		res[k] = MergePatch(res[k], v)
+	}
+	return res
+}
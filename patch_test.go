@@ -0,0 +1,264 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"strings"
+	"testing"
+)
+
+// From RFC 6902 Appendix A, as document, patch, expected result. An empty
+// expected result means the patch must fail.
+var rfcPatchCases = [][3]string{
+	{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+	{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+	{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+	{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+	{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+	{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+		`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+		`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+	{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+	{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+	{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``},
+	{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+	{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"foo":"bar","baz":"qux"}`},
+	{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``},
+	{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+	{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`, ``},
+	{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+}
+
+var patchCases = [][3]string{
+	{`{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	{`[1,2]`, `[{"op":"add","path":"/0","value":0},{"op":"remove","path":"/2"}]`, `[0,1]`},
+	{`{"a":[1]}`, `[{"op":"add","path":"/a/2","value":0}]`, ``},
+	{`{"a":[1]}`, `[{"op":"add","path":"/a/01","value":0}]`, ``},
+	{`{"a":[1]}`, `[{"op":"remove","path":"/a/1"}]`, ``},
+	{`{"a":[1]}`, `[{"op":"remove","path":"/a/-"}]`, ``},
+	{`{"a":1}`, `[{"op":"remove","path":""}]`, ``},
+	{`{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`, ``},
+	{`{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, ``},
+	{`{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a"}]`, `{"a":{"b":1}}`},
+	{`{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a/b","path":"/c"},{"op":"add","path":"/c/-","value":2}]`, `{"a":{"b":[1]},"c":[1,2]}`},
+	{`{"a":1}`, `[{"op":"test","path":"/a","value":1},{"op":"test","path":"","value":{"a":1}}]`, `{"a":1}`},
+	{`{"a":1}`, `[{"op":"frobnicate","path":"/a"}]`, ``},
+	{`{"a":1}`, `[{"op":"add","path":"a","value":1}]`, ``},
+	{`{"a":1}`, `[{"op":"add","path":"/b","value":1},{"op":"test","path":"/a","value":2}]`, ``},
+}
+
+func TestPatchRfcCases(t *testing.T) {
+	for _, c := range rfcPatchCases {
+		assertPatchResult(t, c[0], c[1], c[2])
+	}
+}
+
+func TestPatchCases(t *testing.T) {
+	for _, c := range patchCases {
+		assertPatchResult(t, c[0], c[1], c[2])
+	}
+}
+
+func TestPatchDoesNotChangeDocument(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"a":{"b":[1,2]}}`), &doc)
+	p := Patch{
+		{Op: "add", Path: "/a/b/-", Value: 3},
+		{Op: "remove", Path: "/a/b/0"},
+		{Op: "test", Path: "/a/b/0", Value: 0.0},
+	}
+	_, err := p.Apply(doc)
+	patchErr, ok := err.(*PatchError)
+	if assert.True(t, ok) {
+		assert.Equal(t, 2, patchErr.Index)
+		assert.Equal(t, "Could not apply operation 2 (test '/a/b/0'): Test failed, value is 2", patchErr.Error())
+	}
+	out, _ := json.Marshal(doc)
+	assert.Equal(t, `{"a":{"b":[1,2]}}`, string(out))
+}
+
+func TestOperationMarshalJSON(t *testing.T) {
+	out, _ := json.Marshal(Patch{
+		{Op: "add", Path: "/a", Value: nil},
+		{Op: "remove", Path: "/b", Value: 1},
+		{Op: "move", From: "/c", Path: "/d"},
+	})
+	assert.Equal(t, `[{"op":"add","path":"/a","value":null},{"op":"remove","path":"/b"},{"op":"move","from":"/c","path":"/d"}]`, string(out))
+}
+
+func TestDiff(t *testing.T) {
+	cases := [][2]string{
+		{`{"a":1,"b":[1],"d":{"e":"f"}}`, `{"b":[1,2],"c":3,"d":{"e":"g"}}`},
+		{`{"a":[1,2,3,4]}`, `{"a":[1,3]}`},
+		{`{"a":[1,2]}`, `{"a":{"0":1}}`},
+		{`{"a":null}`, `{"a":false}`},
+		{`[1,{"a":"b"}]`, `[1,{"a":"c"},[]]`},
+		{`{"a":1}`, `"string"`},
+		{`{"a/b":{"~":1}}`, `{"a/b":{"~":2}}`},
+	}
+	for _, c := range cases {
+		var a, b interface{}
+		json.Unmarshal([]byte(c[0]), &a)
+		json.Unmarshal([]byte(c[1]), &b)
+		p := Diff(a, b)
+		res, err := p.Apply(a)
+		assert.Nil(t, err, "Diff %s to %s", c[0], c[1])
+		assert.Equal(t, b, res, "Diff %s to %s", c[0], c[1])
+	}
+}
+
+func TestDiffOperations(t *testing.T) {
+	var a, b interface{}
+	json.Unmarshal([]byte(`{"a":1,"b":[1,2,3],"c":{"d":true}}`), &a)
+	json.Unmarshal([]byte(`{"b":[1,4],"c":{"d":true},"e":null}`), &b)
+	out, _ := json.Marshal(Diff(a, b))
+	assert.Equal(t, `[{"op":"remove","path":"/a"},{"op":"replace","path":"/b/1","value":4},`+
+		`{"op":"remove","path":"/b/2"},{"op":"add","path":"/e","value":null}]`, string(out))
+
+	assert.Equal(t, 0, len(Diff(a, a)))
+}
+
+func TestEqualValuesNumbers(t *testing.T) {
+	equal := [][2]interface{}{
+		{1.0, json.Number("1")},
+		{json.Number("1.0"), json.Number("1")},
+		{json.Number("1e2"), 100},
+		{int64(-5), -5.0},
+		{uint64(7), json.Number("7")},
+		{json.Number("9007199254740993"), uint64(9007199254740993)},
+		{[]interface{}{1.0, map[string]interface{}{"a": int(2)}}, []interface{}{json.Number("1"), map[string]interface{}{"a": 2.0}}},
+	}
+	for _, c := range equal {
+		assert.True(t, equalValues(c[0], c[1]), "%v == %v", c[0], c[1])
+		assert.True(t, equalValues(c[1], c[0]), "%v == %v", c[1], c[0])
+	}
+	different := [][2]interface{}{
+		{1.0, json.Number("1.5")},
+		// float64 can't represent this id, so it is a different number
+		{json.Number("9007199254740993"), 9007199254740992.0},
+		{json.Number("1"), "1"},
+		{json.Number("1"), true},
+		{0, nil},
+		{math.NaN(), math.NaN()},
+	}
+	for _, c := range different {
+		assert.False(t, equalValues(c[0], c[1]), "%v != %v", c[0], c[1])
+		assert.False(t, equalValues(c[1], c[0]), "%v != %v", c[1], c[0])
+	}
+}
+
+func TestPatchAndDiffUseNumber(t *testing.T) {
+	decode := func(s string) interface{} {
+		var v interface{}
+		d := json.NewDecoder(strings.NewReader(s))
+		d.UseNumber()
+		d.Decode(&v)
+		return v
+	}
+	a := decode(`{"id":9007199254740993,"n":1.0,"l":[1,2]}`)
+	b := decode(`{"id":9007199254740992,"n":1,"l":[1,2.0]}`)
+	out, _ := json.Marshal(Diff(a, b))
+	assert.Equal(t, `[{"op":"replace","path":"/id","value":9007199254740992}]`, string(out))
+
+	// test operations compare numbers from either decoding
+	var p Patch
+	json.Unmarshal([]byte(`[{"op":"test","path":"/n","value":1},{"op":"test","path":"/l","value":[1.0,2]}]`), &p)
+	_, err := p.Apply(a)
+	assert.Nil(t, err)
+	p[0].Value = json.Number("1.5")
+	_, err = p.Apply(a)
+	assert.NotNil(t, err)
+
+	_, err = Patch{{Op: "test", Path: "/id", Value: int64(9007199254740993)}}.Apply(a)
+	assert.Nil(t, err)
+	_, err = Patch{{Op: "test", Path: "/id", Value: 9007199254740993.0}}.Apply(a)
+	assert.NotNil(t, err)
+}
+
+func TestMergePatch(t *testing.T) {
+	// From RFC 7386 Appendix A
+	cases := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		var doc, patch, expected interface{}
+		json.Unmarshal([]byte(c[0]), &doc)
+		json.Unmarshal([]byte(c[1]), &patch)
+		json.Unmarshal([]byte(c[2]), &expected)
+		before, _ := json.Marshal(doc)
+		assert.Equal(t, expected, MergePatch(doc, patch), "Merge %s into %s", c[1], c[0])
+		after, _ := json.Marshal(doc)
+		assert.Equal(t, string(before), string(after))
+	}
+}
+
+func ExamplePatch_Apply() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"a":[1,2]}`), &doc)
+
+	res, err := Patch{
+		{Op: "add", Path: "/a/0", Value: 0},
+		{Op: "remove", Path: "/a/2"},
+	}.Apply(doc)
+
+	out, _ := json.Marshal(res)
+	fmt.Printf("%v %s", err == nil, string(out))
+	// Output: true {"a":[0,1]}
+}
+
+func ExampleDiff() {
+	var a, b interface{}
+	json.Unmarshal([]byte(`{"a":1,"b":[1]}`), &a)
+	json.Unmarshal([]byte(`{"b":[1,2],"c":3}`), &b)
+
+	out, _ := json.Marshal(Diff(a, b))
+	fmt.Println(string(out))
+	// Output: [{"op":"remove","path":"/a"},{"op":"add","path":"/b/1","value":2},{"op":"add","path":"/c","value":3}]
+}
+
+func BenchmarkDiffZips(b *testing.B) {
+	from := getZips()
+	to := getZips()
+	Set(to, "/zipcodes/100/pop", 1)
+	Delete(to, "/zipcodes/200")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Diff(from, to)
+	}
+}
+
+func assertPatchResult(t *testing.T, doc, patch, expected string) {
+	var d interface{}
+	var p Patch
+	json.Unmarshal([]byte(doc), &d)
+	if err := json.Unmarshal([]byte(patch), &p); err != nil {
+		t.Fatalf("Invalid patch %s: %v", patch, err)
+	}
+	res, err := p.Apply(d)
+	if expected == "" {
+		assert.NotNil(t, err, "Expected %s to fail on %s", patch, doc)
+		return
+	}
+	if !assert.Nil(t, err, "Patch %s on %s", patch, doc) {
+		return
+	}
+	var e interface{}
+	json.Unmarshal([]byte(expected), &e)
+	assert.Equal(t, e, res, "Patch %s on %s", patch, doc)
+}
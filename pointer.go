@@ -2,33 +2,96 @@ package jsonptr
 
 import (
 	"fmt"
-	"net/url"
+	"math"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Pointer represents a JSON Pointer
 type Pointer struct {
 	path []string
+	// indices holds the array index of each segment, or noIndex, so that
+	// evaluating the pointer doesn't parse them again. It is nil for
+	// pointers that haven't been compiled.
+	indices []int
+	strict  bool
 }
 
+// noIndex marks segments in Pointer.indices that are not array indices.
+// Segments are parsed again when evaluated, so that a real index equal to
+// noIndex still gives the right error.
+const noIndex = math.MinInt32
+
 // New returns a new JSON Pointer from the given string. The string can be a pointer, or a URI Fragment encoded pointer.
+// Array indices in the pointer are parsed once, so a Pointer can be evaluated repeatedly without allocating.
 func New(ptr string) (*Pointer, error) {
+	return parse(ptr, false)
+}
+
+/*
+NewStrict returns a new JSON Pointer from the given string, like New, but
+only accepts pointers that are valid according to RFC 6901. "~" must be
+followed by "0" or "1", and URI fragments must be percent-encoded UTF-8 as
+defined by RFC 3986.
+
+Strict pointers also only accept array indices without leading zeros or
+signs when they are evaluated, so "/01" refers to the key "01" of an object,
+but is an error for an array. Pointers built from a strict pointer, with
+methods like Child, are strict too.
+
+    _, err := jsonptr.NewStrict("/a~2b")
+    // err is not nil, "~2" is not a valid escape
+*/
+func NewStrict(ptr string) (*Pointer, error) {
+	return parse(ptr, true)
+}
+
+// Validate returns an error if ptr is not a valid RFC 6901 JSON pointer or
+// URI fragment identifier. See also NewStrict
+func Validate(ptr string) error {
+	_, err := parse(ptr, true)
+	return err
+}
+
+func parse(ptr string, strict bool) (*Pointer, error) {
 	var path []string
+	var err error
 	if looksLikeURIFragment(ptr) {
-		p, err := decodeURIFragmentIdent(ptr)
-		if err != nil {
-			return nil, err
-		}
-		path = p
+		path, err = decodeURIFragmentIdent(ptr, strict)
 	} else {
-		p, err := decodePointer(ptr)
-		if err != nil {
-			return nil, err
-		}
-		path = p
+		path, err = decodePointer(ptr, strict)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return compile(path, strict), nil
+}
+
+// compile returns a pointer to path with its array indices precomputed.
+func compile(path []string, strict bool) *Pointer {
+	indices := make([]int, len(path))
+	for i, seg := range path {
+		indices[i] = segmentIndex(seg, strict)
+	}
+	return &Pointer{path: path, indices: indices, strict: strict}
+}
+
+// segmentIndex returns the array index of a segment, or noIndex.
+func segmentIndex(seg string, strict bool) int {
+	i, err := arrayIndex(seg, strict)
+	if err != nil {
+		return noIndex
 	}
-	return &Pointer{path}, nil
+	return i
+}
+
+// index returns the array index of the i'th segment of the pointer.
+func (p *Pointer) index(i int) (int, error) {
+	if p.indices != nil && p.indices[i] != noIndex {
+		return p.indices[i], nil
+	}
+	return arrayIndex(p.path[i], p.strict)
 }
 
 // MustConstruct returns a new JSON Pointer from the given string, or panics if the pointer is not valid, like regexp.MustCompile.
@@ -43,36 +106,51 @@ func MustConstruct(ptr string) *Pointer {
 // Get returns the value for the specified location in the document.
 func (p *Pointer) Get(document interface{}) (interface{}, error) {
 	node := document
-	for _, seg := range p.path {
-		switch v := node.(type) {
-		case map[string]interface{}:
-			n, ok := v[seg]
-			if !ok {
-				return nil, fmt.Errorf("Map had no key when evaluating path segment '%s'", seg)
-			}
-			node = n
-			break
-		case []interface{}:
-			if seg == "-" {
-				return nil, fmt.Errorf("Cannot return '%s' index from JSON array", seg)
-			}
-			i, err := strconv.Atoi(seg)
-			if err != nil {
-				return nil, fmt.Errorf("Could not index when evaluating path segment '%s': %v", seg, err)
-			}
-			if i < 0 || i > len(v)-1 {
-				return nil, fmt.Errorf("Slice index %d is out of range (slice len=%d)", i, len(v))
-			}
-			node = v[i]
-			break
-		default:
-			return nil, fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", node)
+	for j, seg := range p.path {
+		idx := noIndex
+		if p.indices != nil {
+			idx = p.indices[j]
+		}
+		n, err := evaluate(node, seg, idx, p.strict)
+		if err != nil {
+			return nil, err
 		}
+		node = n
 	}
 	return node, nil
 }
 
+// evaluate returns the child of node for a path segment. idx is the
+// precomputed array index of the segment, or noIndex to parse it.
+func evaluate(node interface{}, seg string, idx int, strict bool) (interface{}, error) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		n, ok := v[seg]
+		if !ok {
+			return nil, fmt.Errorf("Map had no key when evaluating path segment '%s'", seg)
+		}
+		return n, nil
+	case []interface{}:
+		if seg == "-" {
+			return nil, fmt.Errorf("Cannot return '%s' index from JSON array", seg)
+		}
+		i := idx
+		if i == noIndex {
+			var err error
+			if i, err = arrayIndex(seg, strict); err != nil {
+				return nil, err
+			}
+		}
+		if i < 0 || i > len(v)-1 {
+			return nil, fmt.Errorf("Slice index %d is out of range (slice len=%d)", i, len(v))
+		}
+		return v[i], nil
+	}
+	return nil, fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", node, seg)
+}
+
 // GetBool returns the value for the specified location in the document as a string, or false if not accessible.
+// Use GetAs to tell missing values apart from zero values.
 func (p *Pointer) GetBool(document interface{}) bool {
 	node, _ := p.Get(document)
 	if b, ok := node.(bool); ok {
@@ -82,6 +160,7 @@ func (p *Pointer) GetBool(document interface{}) bool {
 }
 
 // GetString returns the value for the specified location in the document as a string, or an empty string if not accessible.
+// Use GetAs to tell missing values apart from zero values.
 func (p *Pointer) GetString(document interface{}) string {
 	node, _ := p.Get(document)
 	if s, ok := node.(string); ok {
@@ -93,13 +172,13 @@ func (p *Pointer) GetString(document interface{}) string {
 	return ""
 }
 
-// GetNumber returns the value for the specified location in the document as a string, or 0 if not accessible.
+// GetNumber returns the value for the specified location in the document as a float64, or 0 if not accessible.
+// float64, json.Number and integer values are converted.
+// Use GetAs to tell missing values apart from zero values.
 func (p *Pointer) GetNumber(document interface{}) float64 {
 	node, _ := p.Get(document)
-	if f, ok := node.(float64); ok {
-		return f
-	}
-	return 0
+	f, _ := numberValue(node)
+	return f
 }
 
 /*
@@ -115,7 +194,7 @@ type map[string]interface{} or []interface{}, or if it cannot index into an
 array with the provided path segment.
 */
 func (p *Pointer) Set(document interface{}, val interface{}) error {
-	return set(p.path, document, val, false)
+	return set(p.path, document, val, false, p.strict)
 }
 
 /*
@@ -130,10 +209,10 @@ type map[string]interface{} or []interface{}, or if it cannot index into an
 array with the provided path segment.
 */
 func (p *Pointer) Force(document interface{}, val interface{}) error {
-	return set(p.path, document, val, true)
+	return set(p.path, document, val, true, p.strict)
 }
 
-func set(path []string, document interface{}, val interface{}, force bool) error {
+func set(path []string, document interface{}, val interface{}, force, strict bool) error {
 	node := document
 	if len(path) == 0 {
 		return fmt.Errorf("Cannot set root object, set it directly instead")
@@ -165,7 +244,7 @@ func set(path []string, document interface{}, val interface{}, force bool) error
 				if !isLast {
 					if force {
 						node = map[string]interface{}{}
-						if err := set(path[:i], document, append(v, node), false); err != nil {
+						if err := set(path[:i], document, append(v, node), false, strict); err != nil {
 							return err
 						}
 						continue
@@ -173,45 +252,89 @@ func set(path []string, document interface{}, val interface{}, force bool) error
 						return fmt.Errorf("Cannot append to JSON array when not forcing")
 					}
 				} else {
-					return set(path[:i], document, append(v, val), false) // set the immediate parent to the appended slice
+					return set(path[:i], document, append(v, val), false, strict) // set the immediate parent to the appended slice
 				}
 			}
-			i, err := strconv.Atoi(seg)
+			idx, err := arrayIndex(seg, strict)
 			if err != nil {
-				return fmt.Errorf("Could not index when evaluating path segment '%s': %v", seg, err)
+				return err
 			}
-			if i < 0 || (!force && i > len(v)-1) {
-				return fmt.Errorf("Slice index %d is out of range (slice len=%d): %v", i, len(v), err)
+			if idx < 0 || (!force && idx > len(v)-1) {
+				return fmt.Errorf("Slice index %d is out of range (slice len=%d)", idx, len(v))
 			}
-			if force && i > len(v)-1 {
-				sl := make([]interface{}, i+1, i+1)
+			if force && idx > len(v)-1 {
+				sl := make([]interface{}, idx+1, idx+1)
 				copy(sl, v)
 				if !isLast {
-					v[i] = map[string]interface{}{}
+					sl[idx] = map[string]interface{}{}
 				}
 				v = sl
-				if err := set(path[:i], document, sl, false); err != nil {
+				if err := set(path[:i], document, sl, false, strict); err != nil {
 					return err
 				}
 			}
 			if isLast {
-				v[i] = val
+				v[idx] = val
 				return nil
 			}
-			node = v[i]
+			node = v[idx]
 			break
 		default:
-			return fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", node)
+			return fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", node, seg)
 		}
 	}
 	return fmt.Errorf("Could not set value in path")
 }
 
+/*
+Delete removes the specified location from the document, returning an error if
+the location does not exist. Elements after a deleted array element are
+shifted down to fill its place.
+
+Delete cannot delete the root pointer (""), or an element of an array at the
+root of the document, since neither can be changed in place.
+*/
+func (p *Pointer) Delete(document interface{}) error {
+	if len(p.path) == 0 {
+		return fmt.Errorf("Cannot delete root object")
+	}
+	parent := p.path[:len(p.path)-1]
+	seg := p.path[len(p.path)-1]
+	node, err := (&Pointer{path: parent, strict: p.strict}).Get(document)
+	if err != nil {
+		return err
+	}
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if _, ok := v[seg]; !ok {
+			return fmt.Errorf("Map had no key when evaluating path segment '%s'", seg)
+		}
+		delete(v, seg)
+		return nil
+	case []interface{}:
+		i, err := arrayIndex(seg, p.strict)
+		if err != nil {
+			return err
+		}
+		if i < 0 || i > len(v)-1 {
+			return fmt.Errorf("Slice index %d is out of range (slice len=%d)", i, len(v))
+		}
+		if len(parent) == 0 {
+			return fmt.Errorf("Cannot delete from root array")
+		}
+		sl := make([]interface{}, 0, len(v)-1)
+		sl = append(append(sl, v[:i]...), v[i+1:]...)
+		return set(parent, document, sl, false, p.strict)
+	default:
+		return fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", node, seg)
+	}
+}
+
 // Exists returns a boolean indicating whether the pointer location exists in
 // the provided document.
 func (p *Pointer) Exists(document interface{}) bool {
 	node := document
-	for _, seg := range p.path {
+	for j, seg := range p.path {
 		switch v := node.(type) {
 		case map[string]interface{}:
 			n, ok := v[seg]
@@ -221,10 +344,10 @@ func (p *Pointer) Exists(document interface{}) bool {
 			node = n
 			break
 		case []interface{}:
-			if seg == "-" {
+			if seg == "-" || (p.indices != nil && p.indices[j] == noIndex) {
 				return false
 			}
-			i, err := strconv.Atoi(seg)
+			i, err := p.index(j)
 			if err != nil {
 				return false
 			}
@@ -258,47 +381,79 @@ func (p *Pointer) String() string {
 	return fmt.Sprintf("/%s", strings.Join(segments, "/"))
 }
 
-// URIFragmentIdent returns the RFC 6901 URI Fragment representation of the JSON pointer
+// URIFragmentIdent returns the RFC 6901 URI Fragment representation of the JSON pointer,
+// percent-encoded as defined by RFC 3986.
 func (p *Pointer) URIFragmentIdent() string {
-	if len(p.path) == 0 {
-		return "#"
-	}
-	segments := make([]string, len(p.path))
-	copy(segments, p.path)
-	for i, seg := range segments {
-		segments[i] = url.QueryEscape(strings.Replace(strings.Replace(seg, "~", "~0", -1), "/", "~1", -1))
-	}
-	return fmt.Sprintf("#/%s", strings.Join(segments, "/"))
+	return "#" + fragmentEscape(p.String())
 }
 
 func looksLikeURIFragment(ptr string) bool {
 	return strings.HasPrefix(ptr, "#")
 }
 
-func unescape(str string) string {
-	res, _ := url.QueryUnescape(str)
-	if res == "" {
-		return str
+// isFragmentChar reports whether c can appear in a URI fragment without
+// being percent-encoded, according to RFC 3986.
+func isFragmentChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
 	}
-	return res
+	return strings.IndexByte("-._~!$&'()*+,;=:@/?", c) >= 0
 }
 
-func decodeURIFragmentIdent(ptr string) ([]string, error) {
-	if len(ptr) == 1 {
-		return []string{}, nil
+func fragmentEscape(str string) string {
+	var b strings.Builder
+	for i := 0; i < len(str); i++ {
+		if isFragmentChar(str[i]) {
+			b.WriteByte(str[i])
+		} else {
+			fmt.Fprintf(&b, "%%%02X", str[i])
+		}
 	}
-	if !strings.HasPrefix(ptr, "#/") {
-		return nil, fmt.Errorf("Invalid JSON Pointer syntax")
+	return b.String()
+}
+
+// fragmentUnescape decodes the percent-encoded octets of a URI fragment.
+// Invalid escapes are an error when strict, and are kept as they are
+// otherwise.
+func fragmentUnescape(str string, strict bool) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		if c == '%' && i+2 < len(str) && isHex(str[i+1]) && isHex(str[i+2]) {
+			n, _ := strconv.ParseUint(str[i+1:i+3], 16, 8)
+			b.WriteByte(byte(n))
+			i += 2
+			continue
+		}
+		if strict && c == '%' {
+			return "", fmt.Errorf("Invalid percent-encoding in URI fragment '%s'", str)
+		}
+		if strict && !isFragmentChar(c) {
+			return "", fmt.Errorf("Invalid character %q in URI fragment", c)
+		}
+		b.WriteByte(c)
 	}
-	segments := strings.Split(ptr, "/")
-	result := make([]string, len(segments)-1)
-	for i, seg := range segments[1:] {
-		result[i] = strings.Replace(strings.Replace(unescape(seg), "~1", "/", -1), "~0", "~", -1)
+	res := b.String()
+	if strict && !utf8.ValidString(res) {
+		return "", fmt.Errorf("URI fragment '%s' is not UTF-8", str)
 	}
-	return result, nil
+	return res, nil
 }
 
-func decodePointer(ptr string) ([]string, error) {
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func decodeURIFragmentIdent(ptr string, strict bool) ([]string, error) {
+	decoded, err := fragmentUnescape(ptr[1:], strict)
+	if err != nil {
+		return nil, err
+	}
+	return decodePointer(decoded, strict)
+}
+
+func decodePointer(ptr string, strict bool) ([]string, error) {
 	if len(ptr) == 0 {
 		return []string{}, nil
 	}
@@ -308,7 +463,121 @@ func decodePointer(ptr string) ([]string, error) {
 	segments := strings.Split(ptr, "/")
 	result := make([]string, len(segments)-1)
 	for i, seg := range segments[1:] {
+		if strict && !validEscapes(seg) {
+			return nil, fmt.Errorf("Invalid escape sequence in path segment '%s'", seg)
+		}
 		result[i] = strings.Replace(strings.Replace(seg, "~1", "/", -1), "~0", "~", -1)
 	}
 	return result, nil
 }
+
+// validEscapes reports whether every "~" in an encoded segment is followed
+// by "0" or "1".
+func validEscapes(seg string) bool {
+	for i := 0; i < len(seg); i++ {
+		if seg[i] == '~' && (i+1 == len(seg) || (seg[i+1] != '0' && seg[i+1] != '1')) {
+			return false
+		}
+	}
+	return true
+}
+
+// comparePaths orders two decoded paths segment by segment. Array indices
+// sort before all other segments and are compared numerically, so "/2" sorts
+// before "/10" and "/10" before "/1a". Other segments are compared as strings.
+func comparePaths(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		ai, aok := parseIndex(a[i])
+		bi, bok := parseIndex(b[i])
+		if aok != bok {
+			if aok {
+				return -1
+			}
+			return 1
+		}
+		if aok {
+			if ai < bi {
+				return -1
+			}
+			return 1
+		}
+		if a[i] < b[i] {
+			return -1
+		}
+		return 1
+	}
+	return len(a) - len(b)
+}
+
+// hasPathPrefix reports whether every segment of prefix matches the start of
+// path.
+func hasPathPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, seg := range prefix {
+		if path[i] != seg {
+			return false
+		}
+	}
+	return true
+}
+
+// parseIndex parses an array index as defined by RFC 6901, which is either
+// "0" or digits without a leading zero.
+func parseIndex(seg string) (int, bool) {
+	if seg == "" || (seg[0] == '0' && len(seg) > 1) {
+		return 0, false
+	}
+	for i := 0; i < len(seg); i++ {
+		if seg[i] < '0' || seg[i] > '9' {
+			return 0, false
+		}
+	}
+	i, err := strconv.Atoi(seg)
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+// arrayIndex parses a path segment that indexes into an array. Strict
+// pointers only accept RFC 6901 indices, others accept anything that
+// strconv.Atoi does.
+func arrayIndex(seg string, strict bool) (int, error) {
+	if strict {
+		i, ok := parseIndex(seg)
+		if !ok {
+			return 0, fmt.Errorf("Could not index when evaluating path segment '%s': not an array index", seg)
+		}
+		return i, nil
+	}
+	i, err := strconv.Atoi(seg)
+	if err != nil {
+		return 0, fmt.Errorf("Could not index when evaluating path segment '%s': %v", seg, err)
+	}
+	return i, nil
+}
+
+// deepCopy returns a copy of a document that shares no maps or slices with
+// the original.
+func deepCopy(document interface{}) interface{} {
+	switch v := document.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			res[k] = deepCopy(child)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, child := range v {
+			res[i] = deepCopy(child)
+		}
+		return res
+	}
+	return document
+}
@@ -38,7 +38,7 @@ const (
 )
 
 func makePointer(path []string) *Pointer {
-	return &Pointer{path}
+	return &Pointer{path: path}
 }
 
 func TestString(t *testing.T) {
@@ -59,7 +59,7 @@ func TestURIFragmentIdent(t *testing.T) {
 	assert.Equal(t, "#/m~0n", makePointer([]string{"m~n"}).URIFragmentIdent())
 	assert.Equal(t, "#/~01", makePointer([]string{"~1"}).URIFragmentIdent())
 	assert.Equal(t, "#/~1", makePointer([]string{"/"}).URIFragmentIdent())
-	assert.Equal(t, "#/with+space", makePointer([]string{"with space"}).URIFragmentIdent())
+	assert.Equal(t, "#/with%20space", makePointer([]string{"with space"}).URIFragmentIdent())
 	assert.Equal(t, "#/with%5Ecarat", makePointer([]string{"with^carat"}).URIFragmentIdent())
 }
 
@@ -135,6 +135,126 @@ func TestGetRfcFragmentCases(t *testing.T) {
 	assertPointerEvaluatesTo(t, "#/m~0n", rfcDocument, 8)
 }
 
+// rfcStrictCases are the examples of RFC 6901, as a pointer, the equivalent
+// URI fragment identifier, and the value they evaluate to in RfcDoc.
+var rfcStrictCases = []struct {
+	pointer, fragment string
+	value             interface{}
+}{
+	{"/foo/0", "#/foo/0", "bar"},
+	{"/", "#/", 0.0},
+	{"/a~1b", "#/a~1b", 1.0},
+	{"/c%d", "#/c%25d", 2.0},
+	{"/e^f", "#/e%5Ef", 3.0},
+	{"/g|h", "#/g%7Ch", 4.0},
+	{`/i\j`, "#/i%5Cj", 5.0},
+	{`/k"l`, "#/k%22l", 6.0},
+	{"/ ", "#/%20", 7.0},
+	{"/m~0n", "#/m~0n", 8.0},
+}
+
+func TestStrictRfcCases(t *testing.T) {
+	var rfcDocument map[string]interface{}
+	json.Unmarshal([]byte(RfcDoc), &rfcDocument)
+
+	for _, c := range rfcStrictCases {
+		for _, ptr := range []string{c.pointer, c.fragment} {
+			assert.Nil(t, Validate(ptr), "Validate %s", ptr)
+			p, err := NewStrict(ptr)
+			if !assert.Nil(t, err, "NewStrict %s", ptr) {
+				continue
+			}
+			val, err := p.Get(rfcDocument)
+			assert.Nil(t, err, "Get %s", ptr)
+			assert.Equal(t, c.value, val, "Get %s", ptr)
+			assert.Equal(t, c.pointer, p.String())
+			assert.Equal(t, c.fragment, p.URIFragmentIdent())
+		}
+	}
+	for _, ptr := range []string{"", "#", "/foo", "#/foo"} {
+		assert.Nil(t, Validate(ptr), "Validate %s", ptr)
+	}
+}
+
+func TestStrictRejects(t *testing.T) {
+	invalid := []string{
+		"a", "#a", "/a~2b", "/a~", "/~/", "#/a~2b",
+		"#/%", "#/%2", "#/%zz", "#/a b", "#/a\"b", "#/%FF", "#/%C3",
+	}
+	for _, ptr := range invalid {
+		assert.NotNil(t, Validate(ptr), "Validate %s", ptr)
+		_, err := NewStrict(ptr)
+		assert.NotNil(t, err, "NewStrict %s", ptr)
+	}
+
+	// New accepts everything but missing leading slashes
+	for _, ptr := range invalid[2:] {
+		_, err := New(ptr)
+		assert.Nil(t, err, "New %s", ptr)
+	}
+	assert.Equal(t, []string{"a~2b"}, MustConstruct("/a~2b").Path())
+	assert.Equal(t, []string{"%zz"}, MustConstruct("#/%zz").Path())
+}
+
+func TestURIFragmentDecoding(t *testing.T) {
+	// "+" is not a space in URI fragments
+	assert.Equal(t, []string{"a+b"}, MustConstruct("#/a+b").Path())
+	assert.Equal(t, []string{"a b"}, MustConstruct("#/a%20b").Path())
+	// fragments are decoded before they are split into segments
+	assert.Equal(t, []string{"a", "b"}, MustConstruct("#/a%2Fb").Path())
+	assert.Equal(t, []string{"é"}, MustConstruct("#/%C3%A9").Path())
+	assert.Equal(t, "#/%C3%A9", MustConstruct("/é").URIFragmentIdent())
+	assert.Equal(t, "#/!$&'()*+,;=:@?", MustConstruct("/!$&'()*+,;=:@?").URIFragmentIdent())
+
+	for _, seg := range []string{"a b", "a+b", "%", "~/", "é", "#?&="} {
+		p := makePointer([]string{seg})
+		assert.Equal(t, p.Path(), MustConstruct(p.URIFragmentIdent()).Path(), "Round trip %q", seg)
+		p, err := NewStrict(p.URIFragmentIdent())
+		assert.Nil(t, err)
+		assert.Equal(t, []string{seg}, p.Path())
+	}
+}
+
+func TestStrictArrayIndices(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"a":[1,2],"o":{"01":1}}`), &doc)
+
+	for _, ptr := range []string{"/a/01", "/a/+1", "/a/-0", "/a/ 1"} {
+		p, err := NewStrict(ptr)
+		assert.Nil(t, err)
+		_, err = p.Get(doc)
+		assert.NotNil(t, err, "Get %s", ptr)
+		assert.False(t, p.Exists(doc), "Exists %s", ptr)
+		assert.NotNil(t, p.Set(doc, 0), "Set %s", ptr)
+		assert.NotNil(t, p.Delete(doc), "Delete %s", ptr)
+	}
+
+	// leading zeros are fine in object keys
+	p, _ := NewStrict("/o/01")
+	val, err := p.Get(doc)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, val)
+
+	// New accepts what strconv.Atoi does
+	assert.Equal(t, 2.0, MustConstruct("/a/01").GetNumber(doc))
+	assert.Equal(t, 2.0, MustConstruct("/a/+1").GetNumber(doc))
+
+	p, _ = NewStrict("/a/1")
+	assert.Equal(t, 2.0, p.GetNumber(doc))
+	assert.Nil(t, p.Set(doc, 3.0))
+	assert.Nil(t, p.Parent().Append().Set(doc, 4.0))
+	val, _ = MustConstruct("/a").Get(doc)
+	assert.Equal(t, []interface{}{1.0, 3.0, 4.0}, val)
+}
+
+func ExampleValidate() {
+	fmt.Println(Validate("#/c%25d"))
+	fmt.Println(Validate("/a~2b"))
+	// Output:
+	// <nil>
+	// Invalid escape sequence in path segment 'a~2b'
+}
+
 func TestSetEscaping(t *testing.T) {
 	assertSetWorks(t, "#/a~1b")
 	assertSetWorks(t, "#/c%25d")
@@ -176,6 +296,58 @@ func TestForceWithArrayInTheMiddle(t *testing.T) {
 	assert.Equal(t, "value", n4["baz"])
 }
 
+func TestForceExtendsArray(t *testing.T) {
+	res := doForce(t, "/foo/2", `{"foo":["a"]}`, "c")
+	assert.Equal(t, []interface{}{"a", nil, "c"}, res.(map[string]interface{})["foo"])
+
+	res = doForce(t, "/foo/bar/1/baz", `{"foo":{"bar":[]}}`, "value")
+	n1 := res.(map[string]interface{})
+	n2 := n1["foo"].(map[string]interface{})
+	n3 := n2["bar"].([]interface{})
+	assert.Equal(t, 2, len(n3))
+	assert.Nil(t, n3[0])
+	assert.Equal(t, map[string]interface{}{"baz": "value"}, n3[1])
+}
+
+func TestDelete(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(DeepDoc), &doc)
+
+	assert.Nil(t, Delete(doc, "/item"))
+	assert.False(t, Has(doc, "/item"))
+	assert.NotNil(t, Delete(doc, "/item"))
+
+	Force(doc, "/foo/bar/baz/-", "200")
+	Force(doc, "/foo/bar/baz/-", "300")
+	assert.Nil(t, Delete(doc, "/foo/bar/baz/1"))
+	assert.Equal(t, []interface{}{"100", "300"}, doc.(map[string]interface{})["foo"].(map[string]interface{})["bar"].(map[string]interface{})["baz"])
+
+	assert.NotNil(t, Delete(doc, "/foo/bar/baz/2"))
+	assert.NotNil(t, Delete(doc, "/foo/bar/baz/-"))
+	assert.NotNil(t, Delete(doc, "/foo/bar/baz/0/x"))
+	assert.NotNil(t, Delete(doc, "/missing/key"))
+	assert.NotNil(t, Delete(doc, ""))
+}
+
+func TestDeleteRootArray(t *testing.T) {
+	doc := []interface{}{"a", map[string]interface{}{"b": "c"}}
+	assert.NotNil(t, Delete(doc, "/0"))
+	assert.Nil(t, Delete(doc, "/1/b"))
+	assert.Equal(t, []interface{}{"a", map[string]interface{}{}}, doc)
+}
+
+func ExamplePointer_Delete() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"hello":"world","list":[1,2,3]}`), &doc)
+
+	e1 := MustConstruct("/hello").Delete(doc)
+	e2 := MustConstruct("/list/1").Delete(doc)
+
+	out, _ := json.Marshal(doc)
+	fmt.Printf("%v %v %s", e1 == nil, e2 == nil, string(out))
+	// Output: true true {"list":[1,3]}
+}
+
 func TestGetBool(t *testing.T) {
 	doc := getDocWithTypes()
 	p := MustConstruct("/bool")
@@ -214,6 +386,22 @@ func TestGetNumber(t *testing.T) {
 	assert.Equal(t, 0.0, p.GetNumber(doc))
 }
 
+func TestGetNumberTypes(t *testing.T) {
+	doc := map[string]interface{}{
+		"number": json.Number("1.5"),
+		"int":    int(2),
+		"int64":  int64(-3),
+		"uint64": uint64(4),
+		"string": "5",
+	}
+	assert.Equal(t, 1.5, GetNumber(doc, "/number"))
+	assert.Equal(t, 2.0, GetNumber(doc, "/int"))
+	assert.Equal(t, -3.0, GetNumber(doc, "/int64"))
+	assert.Equal(t, 4.0, GetNumber(doc, "/uint64"))
+	assert.Equal(t, 0.0, GetNumber(doc, "/string"))
+	assert.Equal(t, "1.5", GetString(doc, "/number"))
+}
+
 func ExamplePointer_Get() {
 	var doc interface{}
 	json.Unmarshal([]byte(`{"hello":"world"}`), &doc)
@@ -266,6 +454,7 @@ func getZips() interface{} {
 }
 
 func BenchmarkShallowGet(b *testing.B) {
+	b.ReportAllocs()
 	doc := getZips()
 	ptr := MustConstruct("/item")
 
@@ -276,6 +465,7 @@ func BenchmarkShallowGet(b *testing.B) {
 }
 
 func BenchmarkDeepGet(b *testing.B) {
+	b.ReportAllocs()
 	doc := getZips()
 	ptr := MustConstruct("/foo/bar/baz/0")
 
@@ -285,7 +475,46 @@ func BenchmarkDeepGet(b *testing.B) {
 	}
 }
 
+func BenchmarkArrayGet(b *testing.B) {
+	b.ReportAllocs()
+	doc := getZips()
+	ptr := MustConstruct("/zipcodes/1000/loc/1")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ptr.Get(doc)
+	}
+}
+
+// BenchmarkArrayGetUncompiled evaluates a pointer without precomputed
+// indices, to compare with BenchmarkArrayGet.
+func BenchmarkArrayGetUncompiled(b *testing.B) {
+	b.ReportAllocs()
+	doc := getZips()
+	ptr := makePointer([]string{"zipcodes", "1000", "loc", "1"})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ptr.Get(doc)
+	}
+}
+
+// BenchmarkGetRecords evaluates the same pointer against every record.
+func BenchmarkGetRecords(b *testing.B) {
+	b.ReportAllocs()
+	records, _ := MustConstruct("/zipcodes").Get(getZips())
+	ptr := MustConstruct("/loc/0")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range records.([]interface{}) {
+			ptr.GetNumber(r)
+		}
+	}
+}
+
 func BenchmarkShallowExists(b *testing.B) {
+	b.ReportAllocs()
 	doc := getZips()
 	ptr1 := MustConstruct("/item")
 	ptr2 := MustConstruct("/missing")
@@ -298,6 +527,7 @@ func BenchmarkShallowExists(b *testing.B) {
 }
 
 func BenchmarkDeepExists(b *testing.B) {
+	b.ReportAllocs()
 	doc := getZips()
 	ptr1 := MustConstruct("/foo/bar/baz/0")
 	ptr2 := MustConstruct("/foo/bar/baz/7")
@@ -310,6 +540,7 @@ func BenchmarkDeepExists(b *testing.B) {
 }
 
 func BenchmarkShallowSet(b *testing.B) {
+	b.ReportAllocs()
 	doc := getZips()
 	ptr := MustConstruct("/item")
 
@@ -320,6 +551,7 @@ func BenchmarkShallowSet(b *testing.B) {
 }
 
 func BenchmarkDeepSet(b *testing.B) {
+	b.ReportAllocs()
 	doc := getZips()
 	ptr := MustConstruct("/foo/bar/baz/0")
 
@@ -330,6 +562,7 @@ func BenchmarkDeepSet(b *testing.B) {
 }
 
 func BenchmarkShallowForce(b *testing.B) {
+	b.ReportAllocs()
 	doc := getZips()
 
 	b.ResetTimer()
@@ -340,6 +573,7 @@ func BenchmarkShallowForce(b *testing.B) {
 }
 
 func BenchmarkDeepForce(b *testing.B) {
+	b.ReportAllocs()
 	doc := getZips()
 
 	b.ResetTimer()
@@ -349,6 +583,71 @@ func BenchmarkDeepForce(b *testing.B) {
 	}
 }
 
+func TestGetDoesNotAllocate(t *testing.T) {
+	doc := getZips()
+	ptrs := []*Pointer{
+		MustConstruct("/zipcodes/1000/loc/1"),
+		MustConstruct("/zipcodes/1000/city"),
+		MustConstruct("").Child("zipcodes").Index(5).Child("pop"),
+		MustConstruct("/zipcodes/0/loc/0/x"),
+		MustConstruct("/zipcodes/x"),
+		MustConstruct("/zipcodes/99999"),
+	}
+	strict, _ := NewStrict("/zipcodes/01")
+	ptrs = append(ptrs, strict)
+	for _, p := range ptrs {
+		allocs := testing.AllocsPerRun(100, func() {
+			p.Exists(doc)
+		})
+		assert.Equal(t, 0.0, allocs, "Exists %s", p)
+	}
+	for _, p := range ptrs[:3] {
+		allocs := testing.AllocsPerRun(100, func() {
+			p.Get(doc)
+			p.GetNumber(doc)
+		})
+		assert.Equal(t, 0.0, allocs, "Get %s", p)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		ptrs[1].GetString(doc)
+	})
+	assert.Equal(t, 0.0, allocs, "GetString %s", ptrs[1])
+}
+
+func TestCompiledIndices(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"a":[[1,2],[3,4]],"01":{"1":5}}`), &doc)
+	cases := map[*Pointer]interface{}{
+		MustConstruct("/a/1/0"):               3.0,
+		MustConstruct("/a/01/0"):              3.0,
+		MustConstruct("/a").Index(1).Index(1): 4.0,
+		MustConstruct("/a/1/1/x").Parent():   4.0,
+		MustConstruct("/01/1"):               5.0,
+		MustConstruct("/x/a/0/1").Parent().Concat(MustConstruct("/1")).Parent().Parent(): nil,
+	}
+	for p, expected := range cases {
+		val, _ := p.Get(doc)
+		assert.Equal(t, expected, val, "Get %s", p)
+	}
+	rel, _ := MustConstruct("/x/a/0/1").TrimPrefix(MustConstruct("/x"))
+	assert.Equal(t, 2.0, rel.GetNumber(doc))
+	assert.Equal(t, 1.0, CommonAncestor(MustConstruct("/a/0/0/x"), MustConstruct("/a/0/0/y")).GetNumber(doc))
+
+	// a compiled strict pointer still rejects lenient indices, and a lenient
+	// one concatenated onto it becomes strict
+	strict, _ := NewStrict("/a")
+	_, err := strict.Concat(MustConstruct("/01/0")).Get(doc)
+	assert.NotNil(t, err)
+	assert.False(t, strict.Concat(MustConstruct("/01")).Exists(doc))
+	assert.True(t, strict.Concat(MustConstruct("/1")).Exists(doc))
+
+	// index errors are the same as before compiling
+	_, err = MustConstruct("/a/x").Get(doc)
+	assert.Equal(t, `Could not index when evaluating path segment 'x': strconv.Atoi: parsing "x": invalid syntax`, err.Error())
+	_, err = MustConstruct("/a/-1").Get(doc)
+	assert.Equal(t, "Slice index -1 is out of range (slice len=2)", err.Error())
+}
+
 func getDocWithTypes() interface{} {
 	var doc map[string]interface{}
 	json.Unmarshal([]byte(TypeDoc), &doc)
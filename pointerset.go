@@ -0,0 +1,144 @@
+package jsonptr
+
+// PointerSet resolves many pointers against a document at once. Pointers
+// that share a prefix are stored in a trie, so the prefix is only evaluated
+// once per document instead of once per pointer.
+//
+// A PointerSet is not changed by resolving it, so it can be built once and
+// used on many documents, from multiple goroutines.
+type PointerSet struct {
+	pointers []*Pointer
+	root     *setNode
+}
+
+// Result is the outcome of resolving one pointer of a PointerSet. Err is the
+// error that Pointer.Get would have returned for the pointer, and Value is
+// nil when Err is set.
+type Result struct {
+	Pointer *Pointer
+	Value   interface{}
+	Err     error
+}
+
+// setNode is a node of the trie in a PointerSet, holding one path segment.
+type setNode struct {
+	seg      string
+	index    int
+	strict   bool
+	targets  []int // positions of the pointers that end at this node
+	children []*setNode
+	lookup   map[setKey]*setNode
+}
+
+type setKey struct {
+	seg    string
+	strict bool
+}
+
+/*
+NewPointerSet returns a PointerSet containing the given pointers. Pointers may
+be repeated, and each is resolved to its own Result.
+
+    set := jsonptr.NewPointerSet(
+        jsonptr.MustConstruct("/user/name"),
+        jsonptr.MustConstruct("/user/email"),
+        jsonptr.MustConstruct("/user/address/city"),
+    )
+    for _, res := range set.Get(doc) {
+        // res.Value holds the value at res.Pointer, or res.Err the reason it couldn't be found
+    }
+*/
+func NewPointerSet(pointers ...*Pointer) *PointerSet {
+	s := &PointerSet{pointers: pointers, root: &setNode{}}
+	for i, p := range pointers {
+		node := s.root
+		for j, seg := range p.path {
+			idx := noIndex
+			if p.indices != nil {
+				idx = p.indices[j]
+			}
+			node = node.child(seg, idx, p.strict)
+		}
+		node.targets = append(node.targets, i)
+	}
+	return s
+}
+
+// Len returns the number of pointers in the set.
+func (s *PointerSet) Len() int {
+	return len(s.pointers)
+}
+
+// Pointers returns the pointers in the set, in the order they were given.
+func (s *PointerSet) Pointers() []*Pointer {
+	return s.pointers
+}
+
+// Get resolves every pointer in the set against the document, and returns
+// their results in the order the pointers were given.
+func (s *PointerSet) Get(document interface{}) []Result {
+	return s.GetInto(document, nil)
+}
+
+// GetInto is like Get, but stores the results in res if it has enough
+// capacity, so a slice can be reused when resolving many documents.
+func (s *PointerSet) GetInto(document interface{}, res []Result) []Result {
+	if cap(res) < len(s.pointers) {
+		res = make([]Result, len(s.pointers))
+	}
+	res = res[:len(s.pointers)]
+	for i, p := range s.pointers {
+		res[i] = Result{Pointer: p}
+	}
+	s.root.resolve(document, res)
+	return res
+}
+
+// Map resolves every pointer in the set against the document, and returns the
+// values that were found keyed by pointer string.
+func (s *PointerSet) Map(document interface{}) map[string]interface{} {
+	values := make(map[string]interface{}, len(s.pointers))
+	for _, r := range s.Get(document) {
+		if r.Err == nil {
+			values[r.Pointer.String()] = r.Value
+		}
+	}
+	return values
+}
+
+func (n *setNode) child(seg string, idx int, strict bool) *setNode {
+	key := setKey{seg, strict}
+	if c, ok := n.lookup[key]; ok {
+		return c
+	}
+	if n.lookup == nil {
+		n.lookup = make(map[setKey]*setNode)
+	}
+	c := &setNode{seg: seg, index: idx, strict: strict}
+	n.lookup[key] = c
+	n.children = append(n.children, c)
+	return c
+}
+
+func (n *setNode) resolve(val interface{}, res []Result) {
+	for _, i := range n.targets {
+		res[i].Value = val
+	}
+	for _, c := range n.children {
+		v, err := evaluate(val, c.seg, c.index, c.strict)
+		if err != nil {
+			c.fail(err, res)
+			continue
+		}
+		c.resolve(v, res)
+	}
+}
+
+func (n *setNode) fail(err error, res []Result) {
+	for _, i := range n.targets {
+		res[i].Err = err
+	}
+	for _, c := range n.children {
+		c.fail(err, res)
+	}
+}
@@ -0,0 +1,133 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPointerSetMatchesGet(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(RfcDoc), &doc)
+	ptrs := []*Pointer{
+		MustConstruct("/foo/0"),
+		MustConstruct("/foo/1"),
+		MustConstruct("/foo"),
+		MustConstruct(""),
+		MustConstruct("/a~1b"),
+		MustConstruct("/m~0n"),
+		MustConstruct("/foo/2"),
+		MustConstruct("/foo/-"),
+		MustConstruct("/foo/bar"),
+		MustConstruct("/foo/0/x"),
+		MustConstruct("/missing/a/b"),
+		MustConstruct("/ /x"),
+		makePointer([]string{"foo", "1"}),
+		MustConstruct("/foo/0"),
+	}
+	res := NewPointerSet(ptrs...).Get(doc)
+	assert.Len(t, res, len(ptrs))
+	for i, p := range ptrs {
+		val, err := p.Get(doc)
+		assert.True(t, p == res[i].Pointer, "pointer %d", i)
+		assert.Equal(t, val, res[i].Value, "value of %s", p)
+		assert.Equal(t, err, res[i].Err, "error of %s", p)
+	}
+}
+
+func TestPointerSetStrict(t *testing.T) {
+	doc := map[string]interface{}{"a": []interface{}{"x", "y"}}
+	lenient := MustConstruct("/a/01")
+	strict, _ := NewStrict("/a/01")
+	res := NewPointerSet(lenient, strict).Get(doc)
+	assert.Equal(t, "y", res[0].Value)
+	assert.Nil(t, res[0].Err)
+	assert.Nil(t, res[1].Value)
+	assert.Error(t, res[1].Err)
+}
+
+func TestPointerSetGetInto(t *testing.T) {
+	set := NewPointerSet(MustConstruct("/a"), MustConstruct("/b"))
+	res := set.GetInto(map[string]interface{}{"a": 1.0}, nil)
+	assert.Equal(t, 1.0, res[0].Value)
+	assert.Error(t, res[1].Err)
+
+	again := set.GetInto(map[string]interface{}{"b": 2.0}, res)
+	assert.True(t, &res[0] == &again[0])
+	assert.Error(t, again[0].Err)
+	assert.Nil(t, again[0].Value)
+	assert.Equal(t, 2.0, again[1].Value)
+	assert.Nil(t, again[1].Err)
+
+	assert.Equal(t, 2, set.Len())
+	assert.Equal(t, "/b", set.Pointers()[1].String())
+	assert.Empty(t, NewPointerSet().Get(nil))
+}
+
+func TestPointerSetMap(t *testing.T) {
+	zips, _ := MustConstruct("/zipcodes/0").Get(getZips())
+	set := NewPointerSet(MustConstruct("/city"), MustConstruct("/loc/1"), MustConstruct("/county"))
+	assert.Equal(t, map[string]interface{}{"/city": "AGAWAM", "/loc/1": 42.070206}, set.Map(zips))
+}
+
+func ExampleNewPointerSet() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"user": {"name": "Jo", "address": {"city": "Boston"}}}`), &doc)
+
+	set := NewPointerSet(
+		MustConstruct("/user/name"),
+		MustConstruct("/user/address/city"),
+		MustConstruct("/user/email"),
+	)
+	for _, res := range set.Get(doc) {
+		if res.Err != nil {
+			fmt.Println(res.Pointer, "missing")
+			continue
+		}
+		fmt.Println(res.Pointer, res.Value)
+	}
+	// Output:
+	// /user/name Jo
+	// /user/address/city Boston
+	// /user/email missing
+}
+
+// zipFieldPointers returns pointers to several fields of the first records
+// of the zips document, which share their "/zipcodes/N" prefixes.
+func zipFieldPointers() []*Pointer {
+	var ptrs []*Pointer
+	for i := 0; i < 5; i++ {
+		rec := MustConstruct("/zipcodes").Index(i)
+		for _, field := range []string{"city", "state", "pop", "_id"} {
+			ptrs = append(ptrs, rec.Child(field))
+		}
+		ptrs = append(ptrs, rec.Child("loc").Index(0), rec.Child("loc").Index(1))
+	}
+	return ptrs
+}
+
+func BenchmarkPointersSeparately(b *testing.B) {
+	b.ReportAllocs()
+	doc := getZips()
+	ptrs := zipFieldPointers()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range ptrs {
+			p.Get(doc)
+		}
+	}
+}
+
+func BenchmarkPointerSet(b *testing.B) {
+	b.ReportAllocs()
+	doc := getZips()
+	set := NewPointerSet(zipFieldPointers()...)
+	var res []Result
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res = set.GetInto(doc, res)
+	}
+}
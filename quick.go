@@ -1,5 +1,11 @@
 package jsonptr
 
+import (
+	"io"
+	"net/url"
+	"os"
+)
+
 // Get returns the value for the specified location in the document.
 func Get(document interface{}, ptr string) (interface{}, error) {
 	p, err := New(ptr)
@@ -27,7 +33,7 @@ func GetString(document interface{}, ptr string) string {
 	return p.GetString(document)
 }
 
-// GetNumber returns the value for the specified location in the document as a string, or 0 if not accessible.
+// GetNumber returns the value for the specified location in the document as a float64, or 0 if not accessible.
 func GetNumber(document interface{}, ptr string) float64 {
 	p, err := New(ptr)
 	if err != nil {
@@ -66,6 +72,16 @@ func Force(document interface{}, ptr string, val interface{}) error {
 	return p.Force(document, val)
 }
 
+// Delete removes the specified location from the document.
+// See also, Pointer.Delete
+func Delete(document interface{}, ptr string) error {
+	p, err := New(ptr)
+	if err != nil {
+		return err
+	}
+	return p.Delete(document)
+}
+
 /*
 Flatten compacts the provided json document into a map[string]interface{},
 with all keys at the root level. See also Compactor.Flatten
@@ -90,3 +106,57 @@ func Expand(values map[string]interface{}) (interface{}, error) {
 	e := &Expander{}
 	return e.Expand(values)
 }
+
+// WriteCSV writes the array at the specified location in the document to w as
+// CSV, using relative pointers as column headers. See also Tabulator.Write
+func WriteCSV(w io.Writer, document interface{}, ptr string) error {
+	p, err := New(ptr)
+	if err != nil {
+		return err
+	}
+	t := &Tabulator{}
+	return t.Write(w, document, p)
+}
+
+// ReadCSV rebuilds an array from CSV written by WriteCSV. See also
+// Tabulator.Read
+func ReadCSV(r io.Reader) ([]interface{}, error) {
+	t := &Tabulator{}
+	return t.Read(r)
+}
+
+// WriteRecords writes the leaf nodes of the document to w as newline
+// delimited JSON. See also Compactor.WriteRecords
+func WriteRecords(w io.Writer, document interface{}) error {
+	c := &Compactor{}
+	return c.WriteRecords(w, document)
+}
+
+// ReadRecords expands newline delimited JSON records read from r into a full
+// json.Marshal-able document. See also Expander.ReadRecords
+func ReadRecords(r io.Reader) (interface{}, error) {
+	e := &Expander{}
+	return e.ReadRecords(r)
+}
+
+// ApplyEnv applies the process's environment variables that start with prefix
+// onto the document, splitting their names into path segments on separator.
+// See also EnvOverlay.Apply
+func ApplyEnv(document interface{}, prefix, separator string) ([]EnvChange, error) {
+	o := &EnvOverlay{Prefix: prefix, Separator: separator}
+	return o.Apply(document, os.Environ())
+}
+
+// DecodeForm expands url.Values with json pointer field names into a full
+// json.Marshal-able document. See also FormDecoder.Decode
+func DecodeForm(values url.Values) (interface{}, error) {
+	d := &FormDecoder{}
+	return d.Decode(values)
+}
+
+// Search returns the locations of values in the document that match m. See
+// also Searcher.Search
+func Search(document interface{}, m Matcher) []PointerValue {
+	s := &Searcher{}
+	return s.Search(document, m)
+}
@@ -0,0 +1,137 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// pointerRecord is a single line of newline delimited JSON written by
+// Compactor.WriteRecords and read by Expander.ReadRecords.
+type pointerRecord struct {
+	Pointer string      `json:"pointer"`
+	Value   interface{} `json:"value"`
+}
+
+/*
+WriteRecords compacts the provided json document and writes it to w as
+newline delimited JSON, with one record per PointerValue.
+
+    // Given doc is unmarshalled from { "a": [1] }
+    c := &jsonptr.Compactor{}
+    c.WriteRecords(os.Stdout, doc)
+    // {"pointer":"/a/0","value":1}
+*/
+func (c *Compactor) WriteRecords(w io.Writer, document interface{}) error {
+	enc := json.NewEncoder(w)
+	var err error
+	c.visit(document, func(path []string, val interface{}) {
+		if err != nil {
+			return
+		}
+		err = enc.Encode(pointerRecord{c.key(path), val})
+	})
+	return err
+}
+
+/*
+StreamRecords reads a json document from r and writes its leaf nodes to w as
+newline delimited JSON, like WriteRecords, without holding the whole document
+in memory. Records are written in document order, and numbers are written
+exactly as they appear in the input.
+
+StreamRecords returns an error when AllNodes is true, since a node can't be
+written until all of its children have been read.
+*/
+func (c *Compactor) StreamRecords(w io.Writer, r io.Reader) error {
+	if c.AllNodes {
+		return fmt.Errorf("Cannot stream records for all nodes, only leaf nodes can be streamed")
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return c.stream(dec, json.NewEncoder(w), []string{})
+}
+
+func (c *Compactor) stream(dec *json.Decoder, enc *json.Encoder, path []string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if err := c.stream(dec, enc, childpath(path, key.(string))); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := c.stream(dec, enc, childpath(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}
+	return enc.Encode(pointerRecord{c.key(path), tok})
+}
+
+func (c *Compactor) key(path []string) string {
+	p := Pointer{path: path}
+	if c.URIFragment {
+		return p.URIFragmentIdent()
+	}
+	return p.String()
+}
+
+/*
+ReadRecords reads newline delimited JSON records, as written by
+Compactor.WriteRecords, from r and expands them into a full json.Marshal-able
+document. Each record is added to the document as soon as it is read.
+
+A record for the root pointer ("") is only allowed when it is the only record
+in the stream.
+*/
+func (e *Expander) ReadRecords(r io.Reader) (interface{}, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	x := e.begin()
+	var root interface{}
+	hasRoot, count := false, 0
+	for {
+		// the pointer is decoded as a *string to tell a missing pointer from
+		// the root pointer
+		var rec struct {
+			Pointer *string     `json:"pointer"`
+			Value   interface{} `json:"value"`
+		}
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		count++
+		if rec.Pointer == nil {
+			return nil, fmt.Errorf("Record %d has no pointer", count)
+		}
+		if *rec.Pointer == "" || *rec.Pointer == "#" {
+			root, hasRoot = rec.Value, true
+		} else if err := x.add(*rec.Pointer, rec.Value); err != nil {
+			return nil, err
+		}
+		if hasRoot && count > 1 {
+			return nil, fmt.Errorf("Cannot expand a root record together with other records")
+		}
+	}
+	if hasRoot {
+		return root, nil
+	}
+	return x.finish(), nil
+}
@@ -0,0 +1,173 @@
+package jsonptr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWriteRecords(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(SampleDoc), &doc)
+
+	var buf bytes.Buffer
+	err := WriteRecords(&buf, doc)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, len(pointerLeafKeys), len(lines))
+	assert.Contains(t, lines, `{"pointer":"/legumes/2/name","value":"black eyed peas"}`)
+	assert.Contains(t, lines, `{"pointer":"/legumes/3/instock","value":8}`)
+}
+
+func TestWriteRecordsURIFragment(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"a b":[true]}`), &doc)
+
+	var buf bytes.Buffer
+	c := &Compactor{URIFragment: true}
+	err := c.WriteRecords(&buf, doc)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"pointer\":\"#/a%20b/0\",\"value\":true}\n", buf.String())
+}
+
+func TestStreamRecords(t *testing.T) {
+	var buf bytes.Buffer
+	c := &Compactor{}
+	err := c.StreamRecords(&buf, strings.NewReader(`{"id": 12345678901234567890, "a": [{}, [], {"b/c": null}], "d": "e"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"pointer":"/id","value":12345678901234567890}
+{"pointer":"/a/2/b~1c","value":null}
+{"pointer":"/d","value":"e"}
+`, buf.String())
+}
+
+func TestStreamRecordsScalar(t *testing.T) {
+	var buf bytes.Buffer
+	c := &Compactor{}
+	err := c.StreamRecords(&buf, strings.NewReader(`"hello"`))
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"pointer\":\"\",\"value\":\"hello\"}\n", buf.String())
+}
+
+func TestStreamRecordsErrors(t *testing.T) {
+	c := &Compactor{}
+	assert.NotNil(t, c.StreamRecords(ioutil.Discard, strings.NewReader(`{"a": [1, 2}`)))
+	assert.NotNil(t, c.StreamRecords(ioutil.Discard, strings.NewReader(``)))
+
+	c = &Compactor{AllNodes: true}
+	assert.NotNil(t, c.StreamRecords(ioutil.Discard, strings.NewReader(`{}`)))
+}
+
+func TestStreamRecordsMatchesWriteRecords(t *testing.T) {
+	zips, err := ioutil.ReadFile("zips.json")
+	if err != nil {
+		t.Skip("zips.json is not available")
+	}
+	var doc interface{}
+	json.Unmarshal(zips, &doc)
+
+	var written, streamed bytes.Buffer
+	c := &Compactor{}
+	assert.Nil(t, c.WriteRecords(&written, doc))
+	assert.Nil(t, c.StreamRecords(&streamed, bytes.NewReader(zips)))
+
+	w := recordPointers(written.String())
+	s := recordPointers(streamed.String())
+	assert.Equal(t, len(w), len(s))
+	assert.Equal(t, w, s)
+}
+
+// recordPointers returns the sorted pointers of newline delimited records,
+// since streamed numbers keep their original formatting.
+func recordPointers(records string) []string {
+	var res []string
+	dec := json.NewDecoder(strings.NewReader(records))
+	for dec.More() {
+		var rec pointerRecord
+		dec.Decode(&rec)
+		res = append(res, rec.Pointer)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestReadRecords(t *testing.T) {
+	in := `{"pointer":"/a/0","value":1}
+{"pointer":"/a/1","value":{"b":2}}
+{"pointer":"#/c%20d","value":"e"}
+`
+	e := &Expander{DetectArrays: true}
+	doc, err := e.ReadRecords(strings.NewReader(in))
+	assert.Nil(t, err)
+	out, _ := json.Marshal(doc)
+	assert.Equal(t, `{"a":[1,{"b":2}],"c d":"e"}`, string(out))
+}
+
+func TestReadRecordsRoot(t *testing.T) {
+	doc, err := ReadRecords(strings.NewReader(`{"pointer":"","value":[1]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{json.Number("1")}, doc)
+
+	_, err = ReadRecords(strings.NewReader(`{"pointer":"","value":1}
+{"pointer":"/a","value":2}`))
+	assert.NotNil(t, err)
+	_, err = ReadRecords(strings.NewReader(`{"pointer":"/a","value":1}
+{"pointer":"","value":2}`))
+	assert.NotNil(t, err)
+}
+
+func TestReadRecordsErrors(t *testing.T) {
+	_, err := ReadRecords(strings.NewReader(`{"pointer":"/a","value":1`))
+	assert.NotNil(t, err)
+	_, err = ReadRecords(strings.NewReader(`{"pointer":"a","value":1}`))
+	assert.NotNil(t, err)
+	_, err = ReadRecords(strings.NewReader(`{"value":1}`))
+	assert.NotNil(t, err)
+	_, err = ReadRecords(strings.NewReader(`{"pointer":"/a","value":1}
+{"pointer":null,"value":2}`))
+	assert.NotNil(t, err)
+}
+
+func TestRecordsRoundTrip(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(SampleDoc), &doc)
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteRecords(&buf, doc))
+	e := &Expander{DetectArrays: true}
+	res, err := e.ReadRecords(&buf)
+	assert.Nil(t, err)
+
+	expected, _ := json.Marshal(doc)
+	actual, _ := json.Marshal(res)
+	assert.JSONEq(t, string(expected), string(actual))
+}
+
+func ExampleCompactor_StreamRecords() {
+	c := &Compactor{}
+	err := c.StreamRecords(os.Stdout, strings.NewReader(`{"foo":{"bar":["baz", 1.50]}}`))
+	fmt.Println(err == nil)
+	// Output:
+	// {"pointer":"/foo/bar/0","value":"baz"}
+	// {"pointer":"/foo/bar/1","value":1.50}
+	// true
+}
+
+func BenchmarkStreamRecordsZips(b *testing.B) {
+	zips, err := ioutil.ReadFile("zips.json")
+	if err != nil {
+		panic(err)
+	}
+	c := &Compactor{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.StreamRecords(ioutil.Discard, bytes.NewReader(zips))
+	}
+}
@@ -0,0 +1,64 @@
+package jsonptr
+
+// HasPrefix returns true if every segment of prefix matches the start of p,
+// so that the location of p is at or below the location of prefix. Segments
+// are compared decoded, so "/a~1b" has the prefix "#/a~1b" but not "/a".
+func (p *Pointer) HasPrefix(prefix *Pointer) bool {
+	return hasPathPrefix(p.path, prefix.path)
+}
+
+// IsAncestorOf returns true if the location of other is strictly below the
+// location of p.
+func (p *Pointer) IsAncestorOf(other *Pointer) bool {
+	return len(other.path) > len(p.path) && hasPathPrefix(other.path, p.path)
+}
+
+// Equal returns true if both pointers refer to the same location.
+func (p *Pointer) Equal(other *Pointer) bool {
+	return len(p.path) == len(other.path) && hasPathPrefix(p.path, other.path)
+}
+
+/*
+Compare returns -1, 0 or 1 as p sorts before, equal to, or after other.
+Pointers are ordered segment by segment, ancestors before their descendants.
+Array indices sort before other segments and are compared numerically, so
+"/a/2" sorts before "/a/10", which sorts before "/a/1b". Other segments are
+compared as strings.
+
+    sort.Slice(ptrs, func(i, j int) bool { return ptrs[i].Compare(ptrs[j]) < 0 })
+*/
+func (p *Pointer) Compare(other *Pointer) int {
+	c := comparePaths(p.path, other.path)
+	if c < 0 {
+		return -1
+	}
+	if c > 0 {
+		return 1
+	}
+	return 0
+}
+
+/*
+TrimPrefix returns the location of p relative to prefix, and true, if p has
+the prefix. Otherwise it returns p and false.
+
+    rel, ok := jsonptr.MustConstruct("/users/7/name").TrimPrefix(jsonptr.MustConstruct("/users"))
+    // rel.String() == "/7/name", ok == true
+*/
+func (p *Pointer) TrimPrefix(prefix *Pointer) (*Pointer, bool) {
+	if !hasPathPrefix(p.path, prefix.path) {
+		return p, false
+	}
+	return p.slice(len(prefix.path), len(p.path)), true
+}
+
+// CommonAncestor returns the pointer to the deepest location that is at or
+// above both a and b. It is the root pointer if they have no segments in
+// common.
+func CommonAncestor(a, b *Pointer) *Pointer {
+	n := 0
+	for n < len(a.path) && n < len(b.path) && a.path[n] == b.path[n] {
+		n++
+	}
+	return a.slice(0, n)
+}
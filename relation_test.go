@@ -0,0 +1,118 @@
+package jsonptr
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+func TestHasPrefix(t *testing.T) {
+	p := MustConstruct("/a~1b/c/0")
+	assert.True(t, p.HasPrefix(MustConstruct("")))
+	assert.True(t, p.HasPrefix(MustConstruct("#/a~1b")))
+	assert.True(t, p.HasPrefix(MustConstruct("/a~1b/c")))
+	assert.True(t, p.HasPrefix(p))
+	assert.False(t, p.HasPrefix(MustConstruct("/a")))
+	assert.False(t, p.HasPrefix(MustConstruct("/a~1b/c/0/d")))
+	// prefixes are whole segments, not strings
+	assert.False(t, MustConstruct("/ab").HasPrefix(MustConstruct("/a")))
+	assert.False(t, MustConstruct("/a").HasPrefix(MustConstruct("/")))
+}
+
+func TestIsAncestorOf(t *testing.T) {
+	root := MustConstruct("")
+	p := MustConstruct("/a/b")
+	assert.True(t, root.IsAncestorOf(p))
+	assert.True(t, MustConstruct("/a").IsAncestorOf(p))
+	assert.False(t, p.IsAncestorOf(p))
+	assert.False(t, p.IsAncestorOf(MustConstruct("/a")))
+	assert.False(t, MustConstruct("/b").IsAncestorOf(p))
+	assert.False(t, root.IsAncestorOf(root))
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, MustConstruct("/a~1b").Equal(MustConstruct("#/a~1b")))
+	assert.True(t, MustConstruct("").Equal(MustConstruct("#")))
+	assert.False(t, MustConstruct("/a").Equal(MustConstruct("/a/b")))
+	assert.False(t, MustConstruct("/a/b").Equal(MustConstruct("/a")))
+	assert.False(t, MustConstruct("/a").Equal(MustConstruct("/b")))
+	assert.False(t, MustConstruct("").Equal(MustConstruct("/")))
+}
+
+func TestCompare(t *testing.T) {
+	ptrs := []*Pointer{
+		MustConstruct("/b"), MustConstruct("/a/10"), MustConstruct("/a/2"),
+		MustConstruct("/a"), MustConstruct(""), MustConstruct("/a/-"), MustConstruct("/a/02"),
+	}
+	sort.Slice(ptrs, func(i, j int) bool { return ptrs[i].Compare(ptrs[j]) < 0 })
+	sorted := make([]string, len(ptrs))
+	for i, p := range ptrs {
+		sorted[i] = p.String()
+	}
+	assert.Equal(t, []string{"", "/a", "/a/2", "/a/10", "/a/-", "/a/02", "/b"}, sorted)
+
+	assert.Equal(t, 0, MustConstruct("/a").Compare(MustConstruct("#/a")))
+	assert.Equal(t, -1, MustConstruct("/a").Compare(MustConstruct("/a/b/c")))
+	assert.Equal(t, 1, MustConstruct("/a/b/c").Compare(MustConstruct("/a")))
+}
+
+func TestCompareTransitive(t *testing.T) {
+	nine, ten, mixed := MustConstruct("/9"), MustConstruct("/10"), MustConstruct("/1a")
+	assert.Equal(t, -1, nine.Compare(ten))
+	assert.Equal(t, -1, ten.Compare(mixed))
+	assert.Equal(t, -1, nine.Compare(mixed))
+	assert.Equal(t, 1, mixed.Compare(nine))
+
+	ptrs := []*Pointer{mixed, ten, nine, MustConstruct("/1"), MustConstruct("/a")}
+	sort.Slice(ptrs, func(i, j int) bool { return ptrs[i].Compare(ptrs[j]) < 0 })
+	sorted := make([]string, len(ptrs))
+	for i, p := range ptrs {
+		sorted[i] = p.String()
+	}
+	assert.Equal(t, []string{"/1", "/9", "/10", "/1a", "/a"}, sorted)
+}
+
+func TestTrimPrefix(t *testing.T) {
+	p := MustConstruct("/users/7/name")
+	rel, ok := p.TrimPrefix(MustConstruct("/users"))
+	assert.True(t, ok)
+	assert.Equal(t, "/7/name", rel.String())
+
+	rel, ok = p.TrimPrefix(p)
+	assert.True(t, ok)
+	assert.True(t, rel.IsRoot())
+
+	rel, ok = p.TrimPrefix(MustConstruct("/groups"))
+	assert.False(t, ok)
+	assert.Equal(t, p, rel)
+
+	// the remainder can be extended without changing p
+	rel, _ = p.Parent().TrimPrefix(MustConstruct("/users"))
+	assert.Equal(t, "/7/email", rel.Child("email").String())
+	assert.Equal(t, "/users/7/name", p.String())
+
+	assert.True(t, MustConstruct("/users").Concat(rel).Equal(p.Parent()))
+}
+
+func TestCommonAncestor(t *testing.T) {
+	cases := [][3]string{
+		{"/a/b/c", "/a/b/d", "/a/b"},
+		{"/a/b", "/a/b/c", "/a/b"},
+		{"/a/b", "/a/b", "/a/b"},
+		{"/a", "/b", ""},
+		{"", "/b", ""},
+		{"/a~1b/0", "/a~1b/1", "/a~1b"},
+	}
+	for _, c := range cases {
+		ancestor := CommonAncestor(MustConstruct(c[0]), MustConstruct(c[1]))
+		assert.Equal(t, c[2], ancestor.String(), "CommonAncestor(%s, %s)", c[0], c[1])
+	}
+}
+
+func ExamplePointer_TrimPrefix() {
+	p := MustConstruct("/users/7/name")
+	rel, ok := p.TrimPrefix(MustConstruct("/users"))
+	fmt.Println(rel.String(), ok)
+	// Output: /7/name true
+}
@@ -0,0 +1,98 @@
+package jsonptr
+
+import (
+	"regexp"
+	"sort"
+)
+
+// Matcher reports whether a key or value matches a search. Keys are passed
+// to the Matcher as strings.
+type Matcher func(val interface{}) bool
+
+// MatchString returns a Matcher that matches string values equal to s.
+func MatchString(s string) Matcher {
+	return func(val interface{}) bool {
+		str, ok := val.(string)
+		return ok && str == s
+	}
+}
+
+// MatchRegexp returns a Matcher that matches string values containing a
+// match of re.
+func MatchRegexp(re *regexp.Regexp) Matcher {
+	return func(val interface{}) bool {
+		str, ok := val.(string)
+		return ok && re.MatchString(str)
+	}
+}
+
+// MatchRange returns a Matcher that matches numbers between min and max,
+// inclusive. Use math.Inf for an open ended range.
+func MatchRange(min, max float64) Matcher {
+	return func(val interface{}) bool {
+		f, ok := numberValue(val)
+		return ok && f >= min && f <= max
+	}
+}
+
+// SearchTarget determines what a Searcher passes to its Matcher.
+type SearchTarget int
+
+const (
+	// SearchValues matches the values in the document.
+	SearchValues SearchTarget = iota
+	// SearchKeys matches the keys of objects in the document.
+	SearchKeys
+	// SearchAll matches both keys and values.
+	SearchAll
+)
+
+// Searcher contains options for searching a document.
+//
+// Target determines whether values, object keys, or both are matched. When a
+// key matches, the result is the location and value for that key.
+//
+// When LeavesOnly is true, only locations of leaf nodes are returned.
+// Otherwise, objects and arrays are passed to the Matcher too.
+type Searcher struct {
+	Target     SearchTarget
+	LeavesOnly bool
+}
+
+/*
+Search returns the locations in the document that match m, and their values,
+ordered by pointer with array indices in numeric order.
+
+    // Given doc is unmarshalled from {"a": {"city": "AGAWAM"}, "b": ["AGAWAM"]}
+    s := &jsonptr.Searcher{}
+    res := s.Search(doc, jsonptr.MatchString("AGAWAM"))
+    // res[0].Pointer.String() == "/a/city"
+    // res[1].Pointer.String() == "/b/0"
+*/
+func (s *Searcher) Search(document interface{}, m Matcher) []PointerValue {
+	res := make([]PointerValue, 0, 8)
+	c := &Compactor{AllNodes: !s.LeavesOnly}
+	c.visit(document, func(path []string, val interface{}) {
+		if s.matches(document, path, val, m) {
+			res = append(res, PointerValue{Pointer{path: path}, val})
+		}
+	})
+	sort.Slice(res, func(i, j int) bool {
+		return comparePaths(res[i].Pointer.path, res[j].Pointer.path) < 0
+	})
+	return res
+}
+
+func (s *Searcher) matches(document interface{}, path []string, val interface{}, m Matcher) bool {
+	if s.Target != SearchKeys && m(val) {
+		return true
+	}
+	if s.Target == SearchValues || len(path) == 0 {
+		return false
+	}
+	parent, _ := getValue(document, path[:len(path)-1])
+	if _, ok := parent.(map[string]interface{}); !ok {
+		return false
+	}
+	return m(path[len(path)-1])
+}
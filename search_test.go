@@ -0,0 +1,115 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"regexp"
+	"testing"
+)
+
+func searchPointers(res []PointerValue) []string {
+	ptrs := make([]string, len(res))
+	for i, pv := range res {
+		ptrs[i] = pv.Pointer.String()
+	}
+	return ptrs
+}
+
+func TestSearchValues(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(SampleDoc), &doc)
+
+	s := &Searcher{}
+	res := s.Search(doc, MatchString("lbs"))
+	assert.Equal(t, []string{"/legumes/0/unit", "/legumes/1/unit", "/legumes/2/unit", "/legumes/3/unit"}, searchPointers(res))
+	assert.Equal(t, "lbs", res[0].Value)
+
+	res = s.Search(doc, MatchRegexp(regexp.MustCompile("peas$")))
+	assert.Equal(t, []string{"/legumes/2/name", "/legumes/3/name"}, searchPointers(res))
+
+	res = s.Search(doc, MatchRange(8, 13))
+	assert.Equal(t, []string{"/legumes/2/instock", "/legumes/3/instock"}, searchPointers(res))
+
+	res = s.Search(doc, MatchRange(20, math.Inf(1)))
+	assert.Equal(t, []string{"/legumes/1/instock"}, searchPointers(res))
+
+	assert.Equal(t, 0, len(s.Search(doc, MatchString("beans"))))
+}
+
+func TestSearchKeys(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"a":{"name":"x","b":[{"name":{"first":"y"}}]},"name":"name"}`), &doc)
+
+	s := &Searcher{Target: SearchKeys}
+	res := s.Search(doc, MatchString("name"))
+	assert.Equal(t, []string{"/a/b/0/name", "/a/name", "/name"}, searchPointers(res))
+
+	s.LeavesOnly = true
+	res = s.Search(doc, MatchString("name"))
+	assert.Equal(t, []string{"/a/name", "/name"}, searchPointers(res))
+
+	s = &Searcher{Target: SearchAll}
+	res = s.Search(doc, MatchString("y"))
+	assert.Equal(t, []string{"/a/b/0/name/first"}, searchPointers(res))
+
+	// array indices are not keys
+	res = s.Search([]interface{}{"x"}, MatchString("0"))
+	assert.Equal(t, 0, len(res))
+}
+
+func TestSearchLeavesOnly(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"a":[1,[2]],"b":{}}`), &doc)
+	matchAll := func(interface{}) bool { return true }
+
+	s := &Searcher{}
+	assert.Equal(t, []string{"", "/a", "/a/0", "/a/1", "/a/1/0", "/b"}, searchPointers(s.Search(doc, matchAll)))
+
+	s.LeavesOnly = true
+	assert.Equal(t, []string{"/a/0", "/a/1/0"}, searchPointers(s.Search(doc, matchAll)))
+}
+
+func TestSearchOrder(t *testing.T) {
+	doc := getZips()
+	res := Search(doc, MatchRegexp(regexp.MustCompile("^CHICOPEE$")))
+	assert.True(t, len(res) > 1)
+	for i := 1; i < len(res); i++ {
+		assert.True(t, comparePaths(res[i-1].Pointer.path, res[i].Pointer.path) < 0)
+	}
+}
+
+func TestMatchRange(t *testing.T) {
+	m := MatchRange(1, 2)
+	assert.True(t, m(1.5))
+	assert.True(t, m(json.Number("2")))
+	assert.True(t, m(int64(1)))
+	assert.True(t, m(uint8(2)))
+	assert.False(t, m(json.Number("2.5")))
+	assert.False(t, m("1.5"))
+	assert.False(t, m(nil))
+}
+
+func ExampleSearcher_Search() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"a": {"city": "AGAWAM"}, "b": ["AGAWAM", "BARRE"]}`), &doc)
+
+	s := &Searcher{}
+	for _, pv := range s.Search(doc, MatchString("AGAWAM")) {
+		fmt.Println(pv.Pointer.String())
+	}
+	// Output:
+	// /a/city
+	// /b/0
+}
+
+func BenchmarkSearchZips(b *testing.B) {
+	doc := getZips()
+	m := MatchString("AGAWAM")
+	s := &Searcher{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Search(doc, m)
+	}
+}
@@ -0,0 +1,189 @@
+package jsonptr
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Tabulator contains customizable options for how an array of documents can
+// be exported to, and imported from, a delimited table.
+//
+// Each row of the table is one element of the array, flattened with a
+// Compactor. The column headers are the union of the leaf pointers of every
+// row, relative to the row itself.
+//
+// Comma is the field delimiter. When it is zero, ',' is used. Use '\t' for
+// TSV output.
+//
+// Coerce is passed to the Expander that Read uses to rebuild each row. Use
+// InferTypes to read back the non-string values that Write wrote as JSON.
+//
+// Missing is the cell that Write writes for pointers that a row does not
+// have, and that Read omits from the row. When it is empty, missing cells are
+// left empty, so an empty string reads back the same as a missing value. Set
+// it to a string that no value has, such as "\x00", to tell them apart.
+type Tabulator struct {
+	Comma   rune
+	Coerce  Coercer
+	Missing string
+}
+
+// Headers returns the column headers for the array found at rows in the
+// document. Headers are sorted by path, with array indices sorted
+// numerically.
+func (t *Tabulator) Headers(document interface{}, rows *Pointer) ([]string, error) {
+	arr, err := tableRows(document, rows)
+	if err != nil {
+		return nil, err
+	}
+	_, headers := flattenRows(arr)
+	return headers, nil
+}
+
+/*
+Write writes the array found at rows in the document to w as a delimited
+table, with a header row of relative pointers. String values are written as
+is, and all other values are written as JSON. Cells for pointers that a row
+does not have are set to Missing.
+
+    // Given doc is unmarshalled from {"people":[{"name":"ann","age":30}]}
+    t := &jsonptr.Tabulator{}
+    t.Write(os.Stdout, doc, jsonptr.MustConstruct("/people"))
+    // /age,/name
+    // 30,ann
+*/
+func (t *Tabulator) Write(w io.Writer, document interface{}, rows *Pointer) error {
+	arr, err := tableRows(document, rows)
+	if err != nil {
+		return err
+	}
+	flat, headers := flattenRows(arr)
+
+	cw := csv.NewWriter(w)
+	if t.Comma != 0 {
+		cw.Comma = t.Comma
+	}
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	record := make([]string, len(headers))
+	for _, row := range flat {
+		for i, h := range headers {
+			val, ok := row[h]
+			if !ok {
+				record[i] = t.Missing
+				continue
+			}
+			cell, err := formatCell(val)
+			if err != nil {
+				return err
+			}
+			record[i] = cell
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Read reads a delimited table with a header row of relative pointers from r,
+// and rebuilds the array it was exported from. Each row is expanded with an
+// Expander that detects arrays. Cells that equal Missing are omitted from the
+// row, and all other cells are kept as strings unless Coerce converts them.
+func (t *Tabulator) Read(r io.Reader) ([]interface{}, error) {
+	cr := csv.NewReader(r)
+	if t.Comma != 0 {
+		cr.Comma = t.Comma
+	}
+	headers, err := cr.Read()
+	if err == io.EOF {
+		return []interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Expander{DetectArrays: true, Coerce: t.Coerce}
+	res := []interface{}{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		values := map[string]interface{}{}
+		var whole interface{}
+		for i, h := range headers {
+			if record[i] == t.Missing {
+				continue
+			}
+			if h == "" {
+				whole = record[i]
+				continue
+			}
+			values[h] = record[i]
+		}
+		if whole != nil {
+			res = append(res, whole)
+			continue
+		}
+		row, err := e.Expand(values)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, row)
+	}
+	return res, nil
+}
+
+func tableRows(document interface{}, rows *Pointer) ([]interface{}, error) {
+	node, err := rows.Get(document)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Cannot tabulate node of type %T, expected an array", node)
+	}
+	return arr, nil
+}
+
+func flattenRows(arr []interface{}) ([]map[string]interface{}, []string) {
+	c := &Compactor{}
+	flat := make([]map[string]interface{}, len(arr))
+	seen := map[string][]string{}
+	for i, row := range arr {
+		flat[i] = map[string]interface{}{}
+		for _, pv := range c.List(row) {
+			key := pv.Pointer.String()
+			flat[i][key] = pv.Value
+			seen[key] = pv.Pointer.path
+		}
+	}
+	headers := make([]string, 0, len(seen))
+	for k := range seen {
+		headers = append(headers, k)
+	}
+	sort.Slice(headers, func(i, j int) bool {
+		return comparePaths(seen[headers[i]], seen[headers[j]]) < 0
+	})
+	return flat, headers
+}
+
+func formatCell(val interface{}) (string, error) {
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
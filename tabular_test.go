@@ -0,0 +1,150 @@
+package jsonptr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+const TableDoc = `{
+  "rows": [
+    {"name": "first", "loc": [1, 2], "tags": {"a": true}},
+    {"name": "second", "loc": [3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13]},
+    {"name": "with,comma", "extra": null}
+  ]
+}`
+
+func TestTableHeaders(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(TableDoc), &doc)
+
+	tab := &Tabulator{}
+	headers, err := tab.Headers(doc, MustConstruct("/rows"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"/extra",
+		"/loc/0", "/loc/1", "/loc/2", "/loc/3", "/loc/4", "/loc/5",
+		"/loc/6", "/loc/7", "/loc/8", "/loc/9", "/loc/10",
+		"/name", "/tags/a",
+	}, headers)
+}
+
+func TestTableHeadersNotArray(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(TableDoc), &doc)
+
+	tab := &Tabulator{}
+	_, err := tab.Headers(doc, MustConstruct("/rows/0"))
+	assert.NotNil(t, err)
+	_, err = tab.Headers(doc, MustConstruct("/missing"))
+	assert.NotNil(t, err)
+}
+
+func TestTableWrite(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(SampleDoc), &doc)
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, doc, "/legumes")
+	assert.Nil(t, err)
+	assert.Equal(t, `/instock,/name,/unit
+4,pinto beans,lbs
+21,lima beans,lbs
+13,black eyed peas,lbs
+8,split peas,lbs
+`, buf.String())
+}
+
+func TestTableWriteTSV(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(TableDoc), &doc)
+
+	var buf bytes.Buffer
+	tab := &Tabulator{Comma: '\t'}
+	err := tab.Write(&buf, doc, MustConstruct("/rows"))
+	assert.Nil(t, err)
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t, 5, len(lines))
+	assert.Equal(t, "null\t\t\t\t\t\t\t\t\t\t\t\twith,comma\t", lines[3])
+}
+
+func TestTableRoundTrip(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(TableDoc), &doc)
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, doc, "/rows")
+	assert.Nil(t, err)
+
+	rows, err := ReadCSV(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rows))
+
+	out, _ := json.Marshal(rows)
+	assert.Equal(t, `[{"loc":["1","2"],"name":"first","tags":{"a":"true"}},`+
+		`{"loc":["3","4","5","6","7","8","9","10","11","12","13"],"name":"second"},`+
+		`{"extra":"null","name":"with,comma"}]`, string(out))
+}
+
+func TestTableRoundTripMissing(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"rows":[{"a":"","b":"x"},{"b":""},"",{"a":"y"}]}`), &doc)
+
+	var buf bytes.Buffer
+	tab := &Tabulator{Missing: "\x00"}
+	assert.Nil(t, tab.Write(&buf, doc, MustConstruct("/rows")))
+	rows, err := tab.Read(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"a": "", "b": "x"},
+		map[string]interface{}{"b": ""},
+		"",
+		map[string]interface{}{"a": "y"},
+	}, rows)
+
+	// Without a marker, empty strings and missing values are both left empty.
+	buf.Reset()
+	assert.Nil(t, WriteCSV(&buf, doc, "/rows"))
+	assert.Equal(t, ",/a,/b\n,,x\n,,\n,,\n,y,\n", buf.String())
+}
+
+func TestTableReadScalarRows(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader("\"\"\na\nb\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, rows)
+}
+
+func TestTableReadEmpty(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader(""))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(rows))
+}
+
+func ExampleTabulator_Write() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"people":[{"name":"ann","age":30},{"name":"bob"}]}`), &doc)
+	t := &Tabulator{}
+	err := t.Write(os.Stdout, doc, MustConstruct("/people"))
+	fmt.Println(err == nil)
+	// Output:
+	// /age,/name
+	// 30,ann
+	// ,bob
+	// true
+}
+
+func BenchmarkTableWriteZips(b *testing.B) {
+	doc := getZips()
+	t := &Tabulator{}
+	ptr := MustConstruct("/zipcodes")
+	var buf bytes.Buffer
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		t.Write(&buf, doc, ptr)
+	}
+}
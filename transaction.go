@@ -0,0 +1,218 @@
+package jsonptr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/*
+Transaction groups changes to a document so that either all of them are made
+or none are. Changes are made to a copy of the document as they are recorded,
+so each one is checked against the changes before it, and the document itself
+is only changed by Commit.
+
+Every change is recorded as JSON Patch operations, with array indices written
+out in full, so the log returned by Patch can be stored or sent elsewhere and
+applied with Patch.Apply or Document.Patch. Force records an "add" operation
+for each parent it creates.
+
+The document must not be changed by anything else while the transaction is
+open.
+
+    tx := jsonptr.NewTransaction(doc)
+    tx.Set(jsonptr.MustConstruct("/name"), "api")
+    tx.Force(jsonptr.MustConstruct("/db/pool/size"), 10)
+    doc, err := tx.Commit()
+    // if either change failed, err is its error and doc wasn't changed
+*/
+type Transaction struct {
+	document interface{}
+	working  interface{}
+	log      Patch
+	err      error
+	done     bool
+}
+
+// NewTransaction returns a Transaction that changes the given document.
+func NewTransaction(document interface{}) *Transaction {
+	return &Transaction{document: document, working: deepCopy(document)}
+}
+
+// Get returns a copy of the value at the location in the document, including
+// the changes recorded so far.
+func (t *Transaction) Get(p *Pointer) (interface{}, error) {
+	val, err := p.Get(t.working)
+	if err != nil {
+		return nil, err
+	}
+	return deepCopy(val), nil
+}
+
+// Set records setting val at the location, like Pointer.Set. Setting the root
+// pointer replaces the whole document.
+func (t *Transaction) Set(p *Pointer, val interface{}) error {
+	return t.record(setOps(t.working, p, val, false))
+}
+
+// Force records setting val at the location, creating any missing parents,
+// like Pointer.Force. Forcing the root pointer replaces the whole document.
+func (t *Transaction) Force(p *Pointer, val interface{}) error {
+	return t.record(setOps(t.working, p, val, true))
+}
+
+// Delete records removing the location from the document, like
+// Pointer.Delete.
+func (t *Transaction) Delete(p *Pointer) error {
+	path, err := resolvePath(t.working, p)
+	if err != nil {
+		return t.record(nil, err)
+	}
+	if len(path) == 0 {
+		return t.record(nil, fmt.Errorf("Cannot delete root object"))
+	}
+	return t.record(Patch{{Op: "remove", Path: (&Pointer{path: path}).String()}}, nil)
+}
+
+// record applies valid operations to the working copy and adds them to the
+// log, or remembers the first error.
+func (t *Transaction) record(ops Patch, err error) error {
+	if t.done {
+		return errTransactionDone
+	}
+	if err == nil {
+		var res interface{}
+		if res, err = ops.apply(t.working); err == nil {
+			t.working = res
+			t.log = append(t.log, ops...)
+			return nil
+		}
+	}
+	if t.err == nil {
+		t.err = err
+	}
+	return err
+}
+
+// Patch returns the operations recorded so far.
+func (t *Transaction) Patch() Patch {
+	return append(Patch(nil), t.log...)
+}
+
+// Err returns the error of the first change that failed, if any.
+func (t *Transaction) Err() error {
+	return t.err
+}
+
+// Commit makes the recorded changes to the document in place, and returns
+// its new root, which is only different from the document if the root was
+// replaced. If any change failed, Commit returns its error and doesn't change
+// the document.
+func (t *Transaction) Commit() (interface{}, error) {
+	if t.done {
+		return nil, errTransactionDone
+	}
+	t.done = true
+	if t.err != nil {
+		return t.document, t.err
+	}
+	return t.log.apply(t.document)
+}
+
+// Rollback discards the recorded changes, leaving the document unchanged.
+func (t *Transaction) Rollback() {
+	t.done = true
+	t.working = nil
+	t.log = nil
+}
+
+var errTransactionDone = fmt.Errorf("Transaction has already been committed or rolled back")
+
+// setOps returns the operations that set val at p in the document, like set,
+// without changing the document.
+func setOps(document interface{}, p *Pointer, val interface{}, force bool) (Patch, error) {
+	if len(p.path) == 0 {
+		return Patch{{Op: "replace", Path: "", Value: deepCopy(val)}}, nil
+	}
+	var ops Patch
+	add := func(op string, path []string, val interface{}) {
+		ops = append(ops, Operation{Op: op, Path: (&Pointer{path: path}).String(), Value: val})
+	}
+
+	node := document
+	path := make([]string, 0, len(p.path))
+	for i, seg := range p.path {
+		isLast := i == len(p.path)-1
+		switch v := node.(type) {
+		case map[string]interface{}:
+			path = append(path, seg)
+			n, ok := v[seg]
+			switch {
+			case isLast && ok:
+				add("replace", path, deepCopy(val))
+			case isLast:
+				add("add", path, deepCopy(val))
+			case ok:
+				node = n
+			case force:
+				add("add", path, map[string]interface{}{})
+				node = map[string]interface{}{}
+			default:
+				return nil, fmt.Errorf("Map had no key when evaluating path segment '%s'", seg)
+			}
+		case []interface{}:
+			idx := len(v)
+			if seg != "-" {
+				var err error
+				if idx, err = arrayIndex(seg, p.strict); err != nil {
+					return nil, err
+				}
+			} else if !isLast && !force {
+				return nil, fmt.Errorf("Cannot append to JSON array when not forcing")
+			}
+			if idx < 0 || (!force && seg != "-" && idx > len(v)-1) {
+				return nil, fmt.Errorf("Slice index %d is out of range (slice len=%d)", idx, len(v))
+			}
+			for j := len(v); j < idx; j++ {
+				add("add", append(path, strconv.Itoa(j)), nil)
+			}
+			path = append(path, strconv.Itoa(idx))
+			switch {
+			case isLast && idx < len(v):
+				add("replace", path, deepCopy(val))
+			case isLast:
+				add("add", path, deepCopy(val))
+			case idx < len(v):
+				node = v[idx]
+			default:
+				add("add", path, map[string]interface{}{})
+				node = map[string]interface{}{}
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported node type %T when evaluating path segment '%s'", node, seg)
+		}
+	}
+	return ops, nil
+}
+
+// resolvePath returns the path of an existing location in the document, with
+// array indices in canonical form.
+func resolvePath(document interface{}, p *Pointer) ([]string, error) {
+	node := document
+	path := make([]string, len(p.path))
+	for i, seg := range p.path {
+		path[i] = seg
+		if _, ok := node.([]interface{}); ok && seg != "-" {
+			idx, err := arrayIndex(seg, p.strict)
+			if err != nil {
+				return nil, err
+			}
+			path[i] = strconv.Itoa(idx)
+		}
+		n, err := evaluate(node, seg, noIndex, p.strict)
+		if err != nil {
+			return nil, err
+		}
+		node = n
+	}
+	return path, nil
+}
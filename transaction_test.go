@@ -0,0 +1,156 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func transactionDoc() interface{} {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"name": "svc", "db": {"hosts": ["a", "b"]}}`), &doc)
+	return doc
+}
+
+func TestTransactionCommit(t *testing.T) {
+	doc := transactionDoc()
+	tx := NewTransaction(doc)
+	assert.Nil(t, tx.Set(MustConstruct("/name"), "api"))
+	assert.Nil(t, tx.Set(MustConstruct("/db/hosts/-"), "c"))
+	assert.Nil(t, tx.Set(MustConstruct("/db/hosts/01"), "B"))
+	assert.Nil(t, tx.Force(MustConstruct("/cache/pool/size"), 10.0))
+	assert.Nil(t, tx.Delete(MustConstruct("/db/hosts/0")))
+
+	val, err := tx.Get(MustConstruct("/db/hosts"))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"B", "c"}, val)
+	assert.Equal(t, transactionDoc(), doc, "document changed before commit")
+
+	out, _ := json.Marshal(tx.Patch())
+	assert.Equal(t, `[{"op":"replace","path":"/name","value":"api"},`+
+		`{"op":"add","path":"/db/hosts/2","value":"c"},`+
+		`{"op":"replace","path":"/db/hosts/1","value":"B"},`+
+		`{"op":"add","path":"/cache","value":{}},`+
+		`{"op":"add","path":"/cache/pool","value":{}},`+
+		`{"op":"add","path":"/cache/pool/size","value":10},`+
+		`{"op":"remove","path":"/db/hosts/0"}]`, string(out))
+
+	res, err := tx.Commit()
+	assert.Nil(t, err)
+	expected := map[string]interface{}{
+		"name":  "api",
+		"db":    map[string]interface{}{"hosts": []interface{}{"B", "c"}},
+		"cache": map[string]interface{}{"pool": map[string]interface{}{"size": 10.0}},
+	}
+	assert.Equal(t, expected, res)
+	assert.Equal(t, expected, doc, "document not changed in place")
+
+	patched, err := tx.Patch().Apply(transactionDoc())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, patched)
+
+	_, err = tx.Commit()
+	assert.Error(t, err)
+	assert.Error(t, tx.Set(MustConstruct("/name"), "x"))
+}
+
+func TestTransactionFailure(t *testing.T) {
+	doc := transactionDoc()
+	tx := NewTransaction(doc)
+	assert.Nil(t, tx.Set(MustConstruct("/name"), "api"))
+	err := tx.Force(MustConstruct("/name/first/x"), 1.0)
+	assert.Equal(t, MustConstruct("/name/first/x").Force(transactionDoc(), 1.0), err)
+	assert.Error(t, tx.Set(MustConstruct("/missing/x"), 1.0))
+	assert.Error(t, tx.Delete(MustConstruct("/db/hosts/5")))
+	assert.Error(t, tx.Delete(MustConstruct("")))
+	assert.Len(t, tx.Patch(), 1)
+	assert.Equal(t, err, tx.Err())
+
+	res, commitErr := tx.Commit()
+	assert.Equal(t, err, commitErr)
+	assert.Equal(t, transactionDoc(), res)
+	assert.Equal(t, transactionDoc(), doc)
+}
+
+func TestTransactionForceLeavesNothingOnFailure(t *testing.T) {
+	doc := map[string]interface{}{"a": "leaf", "arr": []interface{}{}}
+	tx := NewTransaction(doc)
+	assert.Error(t, tx.Force(MustConstruct("/arr/x/y"), 1.0))
+	assert.Error(t, tx.Force(MustConstruct("/arr/-1"), 1.0))
+	assert.Error(t, tx.Force(MustConstruct("/a/b"), 1.0))
+	assert.Empty(t, tx.Patch())
+	val, _ := tx.Get(MustConstruct(""))
+	assert.Equal(t, map[string]interface{}{"a": "leaf", "arr": []interface{}{}}, val)
+}
+
+func TestTransactionMatchesForce(t *testing.T) {
+	cases := []string{"/x/y/z", "/arr/3", "/arr/-/name", "/arr/5/name", "/arr/0", "/obj/-"}
+	for _, ptr := range cases {
+		doc := map[string]interface{}{"arr": []interface{}{1.0}, "obj": map[string]interface{}{}}
+		expected := deepCopy(doc)
+		assert.Nil(t, MustConstruct(ptr).Force(expected, "v"), ptr)
+
+		tx := NewTransaction(doc)
+		assert.Nil(t, tx.Force(MustConstruct(ptr), "v"), ptr)
+		res, err := tx.Commit()
+		assert.Nil(t, err, ptr)
+		assert.Equal(t, expected, res, ptr)
+	}
+}
+
+func TestTransactionRoot(t *testing.T) {
+	tx := NewTransaction([]interface{}{1.0, 2.0})
+	assert.Nil(t, tx.Delete(MustConstruct("/0")))
+	assert.Nil(t, tx.Set(MustConstruct("/-"), 3.0))
+	res, err := tx.Commit()
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{2.0, 3.0}, res)
+
+	tx = NewTransaction(map[string]interface{}{"a": 1.0})
+	assert.Nil(t, tx.Set(MustConstruct(""), "replaced"))
+	res, _ = tx.Commit()
+	assert.Equal(t, "replaced", res)
+}
+
+func TestTransactionRollback(t *testing.T) {
+	doc := transactionDoc()
+	tx := NewTransaction(doc)
+	tx.Set(MustConstruct("/name"), "api")
+	tx.Rollback()
+	assert.Empty(t, tx.Patch())
+	_, err := tx.Commit()
+	assert.Error(t, err)
+	assert.Equal(t, transactionDoc(), doc)
+}
+
+func TestTransactionDocument(t *testing.T) {
+	d := NewDocument(transactionDoc())
+	tx := NewTransaction(d.Snapshot())
+	tx.Force(MustConstruct("/db/port"), 5432.0)
+	tx.Delete(MustConstruct("/name"))
+	assert.Nil(t, d.Patch(tx.Patch()))
+	assert.False(t, d.Exists(MustConstruct("/name")))
+	val, _ := d.Get(MustConstruct("/db/port"))
+	assert.Equal(t, 5432.0, val)
+}
+
+func ExampleTransaction() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"name": "svc"}`), &doc)
+
+	tx := NewTransaction(doc)
+	tx.Set(MustConstruct("/name"), "api")
+	tx.Force(MustConstruct("/db/pool/size"), 10)
+	if _, err := tx.Commit(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	log, _ := json.Marshal(tx.Patch())
+	out, _ := json.Marshal(doc)
+	fmt.Println(string(log))
+	fmt.Println(string(out))
+	// Output:
+	// [{"op":"replace","path":"/name","value":"api"},{"op":"add","path":"/db","value":{}},{"op":"add","path":"/db/pool","value":{}},{"op":"add","path":"/db/pool/size","value":10}]
+	// {"db":{"pool":{"size":10}},"name":"api"}
+}
@@ -0,0 +1,435 @@
+package jsonptr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/*
+Transform transforms two patches that were made concurrently against the
+same document, so that each can be applied after the other. It returns a2,
+which has the effect of a when applied after b, and b2, which has the effect
+of b when applied after a, so both orders give the same document:
+
+    a2, b2, err := jsonptr.Transform(doc, a, b)
+    // a.Apply(doc) followed by b2, and b.Apply(doc) followed by a2, are equal
+
+The document is the one both patches were made against. It's used to tell
+arrays from objects, and isn't changed.
+
+Pointers are adjusted for the changes made by the other patch: array indices
+shift for inserted and removed elements, and locations inside moved values
+follow them. Changes to locations that the other patch removes or replaces,
+or to values inside them, are dropped, so removing a value always wins over
+changing it. A value moved or copied out of a removed value is still added
+where it was moved or copied to. When both patches set the same location,
+move the same value, or insert into the same array position, a wins. Changes
+made by one patch inside a value that the other patch copies are also made
+to the copy.
+
+Transform returns an error if either patch doesn't apply to the document, or
+if the transformed patches don't converge, for example because a "test"
+operation fails after the other patch changed the value it tests, or because
+each patch moves a value into the one the other moves.
+*/
+func Transform(document interface{}, a, b Patch) (Patch, Patch, error) {
+	docA, err := a.Apply(document)
+	if err != nil {
+		return nil, nil, err
+	}
+	docB, err := b.Apply(document)
+	if err != nil {
+		return nil, nil, err
+	}
+	a2, b2, err := transform(document, a, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	resA, err := b2.Apply(docA)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Cannot transform patches: %v", err)
+	}
+	resB, err := a2.Apply(docB)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Cannot transform patches: %v", err)
+	}
+	if !equalValues(resA, resB) {
+		return nil, nil, fmt.Errorf("Cannot transform patches, their changes conflict")
+	}
+	return a2, b2, nil
+}
+
+// transform transforms a and b, which both apply to the document, against
+// each other one operation at a time.
+func transform(document interface{}, a, b Patch) (Patch, Patch, error) {
+	if len(a) == 0 || len(b) == 0 {
+		return a, b, nil
+	}
+	if len(a) > 1 {
+		a1, b1, err := transform(document, a[:1], b)
+		if err != nil {
+			return nil, nil, err
+		}
+		doc, err := a[:1].Apply(document)
+		if err != nil {
+			return nil, nil, err
+		}
+		a2, b2, err := transform(doc, a[1:], b1)
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(a1, a2...), b2, nil
+	}
+	if len(b) > 1 {
+		a1, b1, err := transform(document, a, b[:1])
+		if err != nil {
+			return nil, nil, err
+		}
+		doc, err := b[:1].Apply(document)
+		if err != nil {
+			return nil, nil, err
+		}
+		a2, b2, err := transform(doc, a1, b[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		return a2, append(b1, b2...), nil
+	}
+
+	x, err := newEdit(document, a[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	y, err := newEdit(document, b[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return y.transform(x, true), x.transform(y, false), nil
+}
+
+// The ways an operation uses a location, which decide how it is affected by
+// another operation changing that location.
+const (
+	roleFrom   = iota // the source of a copy
+	roleMove          // the source of a move
+	roleInsert        // the position an element is inserted at in an array
+	roleSet           // the location a value is added to an object or replaced at
+	roleRemove        // the location a value is removed from
+	roleTest          // the location a value is tested at
+)
+
+// edit is an operation with its paths resolved against the document it
+// applies to, with "-" replaced by an index and array indices in canonical
+// form.
+type edit struct {
+	op    string
+	value interface{} // the value set, or the value moved or copied
+	after interface{} // the document after the operation
+
+	removed        []string // location removed by remove and move
+	removedInArray bool
+	source         []string // location copied by copy
+	target         []string // location set by add, replace, move and copy, after any removal
+	insert         bool     // target is inserted into an array
+	replaced       bool     // target had a value before, which was replaced
+	tested         []string
+}
+
+func newEdit(document interface{}, op Operation) (*edit, error) {
+	after, err := Patch{op}.Apply(document)
+	if err != nil {
+		return nil, err
+	}
+	e := &edit{op: op.Op, value: op.Value, after: after}
+	path, err := New(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "move", "copy":
+		e.target = canonicalPath(after, path.path)
+		if op.Op != "replace" && len(e.target) > 0 {
+			e.insert = isArray(parentValue(after, e.target))
+		}
+	case "remove":
+		e.removed = canonicalPath(document, path.path)
+		e.removedInArray = isArray(parentValue(document, e.removed))
+	case "test":
+		e.tested = canonicalPath(document, path.path)
+	}
+	if op.Op == "move" || op.Op == "copy" {
+		from, err := New(op.From)
+		if err != nil {
+			return nil, err
+		}
+		// keep the value, in case the other operation removes it
+		e.value, _ = getValue(document, from.path)
+		if op.Op == "copy" {
+			e.source = canonicalPath(document, from.path)
+			if !e.insert && comparePaths(e.source, e.target) == 0 {
+				// copying a value over itself doesn't change anything
+				return &edit{after: after}, nil
+			}
+		} else {
+			e.removed = canonicalPath(document, from.path)
+			e.removedInArray = isArray(parentValue(document, e.removed))
+			if comparePaths(e.removed, e.target) == 0 {
+				// moving a value to where it is doesn't change anything
+				return &edit{after: after}, nil
+			}
+			// the target of a move is found after removing the value
+			if document, err = (Patch{{Op: "remove", Path: op.From}}).Apply(document); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if e.target != nil && !e.insert {
+		_, err := getValue(document, e.target)
+		e.replaced = err == nil
+	}
+	return e, nil
+}
+
+// transform returns the operations that have the effect of x when applied
+// after e, where both x and e applied to the same document. When wins is
+// true, x wins over e when both set the same location.
+func (e *edit) transform(x *edit, wins bool) Patch {
+	res := *x
+	if x.tested != nil {
+		t, ok := e.mapPath(x.tested, roleTest, wins)
+		if !ok {
+			return nil
+		}
+		res.tested = t
+	}
+	if x.op == "remove" {
+		r, ok := e.mapPath(x.removed, roleRemove, wins)
+		if !ok {
+			return nil
+		}
+		res.removed = r
+	}
+	target := x.target
+	if x.op == "move" {
+		if x.removedInArray {
+			// the target of a move is found after removing the value, so
+			// find where it was before
+			target = shiftIndex(target, x.removed, 1, false)
+		}
+		r, ok := e.mapPath(x.removed, roleMove, wins)
+		switch {
+		case ok:
+			res.removed = r
+			res.removedInArray = isArray(parentValue(e.after, r))
+		case e.removed != nil && comparePaths(x.removed, e.removed) == 0:
+			// e removed or moved the same value, leaving only the value x
+			// replaced with it to remove
+			if !x.replaced {
+				return nil
+			}
+			t, ok := e.mapPath(target, roleRemove, wins)
+			if !ok {
+				return nil
+			}
+			res.op, res.removed, res.target = "remove", t, nil
+			return res.operations()
+		default:
+			// e removed or replaced a parent of the value, so add the value
+			// that was moved instead
+			res.op, res.removed = "add", nil
+		}
+	}
+	if x.op == "copy" {
+		s, ok := e.mapPath(x.source, roleFrom, wins)
+		if ok {
+			res.source = s
+		} else {
+			res.op, res.source = "add", nil
+		}
+	}
+	if x.target != nil {
+		role := roleSet
+		if x.insert {
+			role = roleInsert
+		}
+		t, ok := e.mapPath(target, role, wins)
+		if !ok {
+			if res.op == "move" {
+				// the value moved to somewhere that e replaced, so it's gone
+				res.op, res.target = "remove", nil
+				return append(e.mirror(x), res.operations()...)
+			}
+			return nil
+		}
+		if res.op == "move" && res.removedInArray {
+			t = shiftIndex(t, res.removed, -1, false)
+		}
+		res.target = t
+	}
+	return append(e.mirror(x), res.operations()...)
+}
+
+// mapPath returns where a location used by another operation is after e, or
+// false if e removed or replaced it.
+func (e *edit) mapPath(path []string, role int, wins bool) ([]string, bool) {
+	if e.removed != nil {
+		if hasPathPrefix(path, e.removed) && !(role == roleInsert && len(path) == len(e.removed)) {
+			if e.op != "move" || (len(path) == len(e.removed) && role == roleMove && !wins) {
+				return nil, false
+			}
+			// the location moved along with the value
+			return rebase(path, e.removed, e.target), true
+		}
+		if e.removedInArray {
+			path = shiftIndex(path, e.removed, -1, false)
+		}
+	}
+	if e.target != nil {
+		if e.insert {
+			tie := role == roleInsert && len(path) == len(e.target) && wins
+			path = shiftIndex(path, e.target, 1, tie)
+		} else if hasPathPrefix(path, e.target) {
+			if e.op == "copy" && hasPathPrefix(e.source, e.target) && hasPathPrefix(path, e.source) &&
+				!(role == roleRemove && len(path) == len(e.source)) {
+				// the value was copied over a value containing it
+				return rebase(path, e.source, e.target), true
+			}
+			if len(path) > len(e.target) || (role == roleSet && !wins) {
+				return nil, false
+			}
+		}
+	}
+	return path, true
+}
+
+// mirror returns the operations that make the changes x makes inside the
+// value e copied to the copy as well, to be applied before x transformed
+// against e.
+func (e *edit) mirror(x *edit) Patch {
+	if e.op != "copy" || (!e.insert && hasPathPrefix(e.source, e.target)) {
+		// the copy replaced the value it copied
+		return nil
+	}
+	// inside returns true if a path, which is a location or an insert
+	// position as given, is inside the copied value
+	inside := func(path []string, insert bool) bool {
+		if !e.insert && hasPathPrefix(path, e.target) {
+			// the copy replaced it
+			return false
+		}
+		return hasPathPrefix(path, e.source) && (len(path) > len(e.source) || !insert)
+	}
+	str := func(path []string) string {
+		return (&Pointer{path: rebase(path, e.source, e.target)}).String()
+	}
+	// set sets the value x sets in the copy. Copies and moves are made with
+	// the value itself, so a value copied into itself isn't copied again.
+	set := func() Patch {
+		op := "add"
+		if x.op == "replace" || (e.insert && len(x.target) == len(e.source)) {
+			// the copy is an array element, so setting all of it replaces it
+			op = "replace"
+		}
+		return Patch{{Op: op, Path: str(x.target), Value: x.value}}
+	}
+	switch x.op {
+	case "add", "replace", "copy":
+		if inside(x.target, x.insert) {
+			return set()
+		}
+	case "remove":
+		if inside(x.removed, true) {
+			return Patch{{Op: "remove", Path: str(x.removed)}}
+		}
+	case "move":
+		from, to := inside(x.removed, true), inside(x.target, x.insert)
+		switch {
+		case from && to:
+			return Patch{{Op: "move", From: str(x.removed), Path: str(x.target)}}
+		case from:
+			return Patch{{Op: "remove", Path: str(x.removed)}}
+		case to:
+			return set()
+		}
+	}
+	return nil
+}
+
+// rebase returns path, which is inside from, moved inside to.
+func rebase(path, from, to []string) []string {
+	res := make([]string, 0, len(to)+len(path)-len(from))
+	return append(append(res, to...), path[len(from):]...)
+}
+
+func (e *edit) operations() Patch {
+	str := func(path []string) string {
+		return (&Pointer{path: path}).String()
+	}
+	switch e.op {
+	case "add", "replace":
+		return Patch{{Op: e.op, Path: str(e.target), Value: e.value}}
+	case "remove":
+		return Patch{{Op: e.op, Path: str(e.removed)}}
+	case "move":
+		return Patch{{Op: e.op, From: str(e.removed), Path: str(e.target)}}
+	case "copy":
+		return Patch{{Op: e.op, From: str(e.source), Path: str(e.target)}}
+	case "test":
+		return Patch{{Op: e.op, Path: str(e.tested), Value: e.value}}
+	}
+	return nil
+}
+
+// shiftIndex returns path with the index it has in the array containing at
+// moved by delta, if it is after at. Paths at the same index are moved too
+// when delta is positive, unless tie is true.
+func shiftIndex(path, at []string, delta int, tie bool) []string {
+	n := len(at)
+	if n == 0 || len(path) < n || !hasPathPrefix(path, at[:n-1]) {
+		return path
+	}
+	j, err := strconv.Atoi(path[n-1])
+	if err != nil {
+		return path
+	}
+	i, err := strconv.Atoi(at[n-1])
+	if err != nil {
+		return path
+	}
+	if j > i || (delta > 0 && j == i && !tie) {
+		res := append([]string{}, path...)
+		res[n-1] = strconv.Itoa(j + delta)
+		return res
+	}
+	return path
+}
+
+// canonicalPath returns the path with the array indices in the document
+// written as plain numbers, and a trailing "-" replaced by the index of the
+// last element, which is where an element appended to the document went.
+func canonicalPath(document interface{}, path []string) []string {
+	res := make([]string, len(path))
+	node := document
+	for i, seg := range path {
+		res[i] = seg
+		if arr, ok := node.([]interface{}); ok {
+			if seg == "-" {
+				res[i] = strconv.Itoa(len(arr) - 1)
+			} else if idx, err := arrayIndex(seg, false); err == nil {
+				res[i] = strconv.Itoa(idx)
+			}
+		}
+		node, _ = evaluate(node, res[i], noIndex, false)
+	}
+	return res
+}
+
+// parentValue returns the value containing the location, or nil for the
+// root or a location without a parent.
+func parentValue(document interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return nil
+	}
+	val, _ := getValue(document, path[:len(path)-1])
+	return val
+}
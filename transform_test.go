@@ -0,0 +1,279 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// assertTransforms transforms patches a and b, given as JSON, against each
+// other, and checks the transformed patches and that both orders converge.
+func assertTransforms(t *testing.T, doc, a, b, a2, b2 string) {
+	var pa, pb Patch
+	json.Unmarshal([]byte(a), &pa)
+	json.Unmarshal([]byte(b), &pb)
+	ta, tb := assertConverges(t, doc, pa, pb)
+	ja, _ := json.Marshal(append(Patch{}, ta...))
+	jb, _ := json.Marshal(append(Patch{}, tb...))
+	assert.JSONEq(t, a2, string(ja), "transforming %s against %s", a, b)
+	assert.JSONEq(t, b2, string(jb), "transforming %s against %s", b, a)
+}
+
+// assertConverges transforms a and b against each other, and checks that
+// applying them in either order gives the same document.
+func assertConverges(t *testing.T, doc string, a, b Patch) (Patch, Patch) {
+	var base interface{}
+	json.Unmarshal([]byte(doc), &base)
+	a2, b2, err := Transform(base, a, b)
+	if !assert.Nil(t, err, "transforming %v and %v against %s", a, b, doc) {
+		return nil, nil
+	}
+	docA, _ := a.Apply(base)
+	docB, _ := b.Apply(base)
+	resA, err := b2.Apply(docA)
+	assert.Nil(t, err)
+	resB, err := a2.Apply(docB)
+	assert.Nil(t, err)
+	assert.Equal(t, resA, resB)
+	return a2, b2
+}
+
+// randomPatch returns a patch of n random operations that applies to the
+// document, using the first kinds of "add", "remove", "replace", "move" and
+// "copy".
+func randomPatch(r *rand.Rand, doc interface{}, n, kinds int) Patch {
+	var p Patch
+	for len(p) < n {
+		op, ok := randomOperation(r, doc, kinds)
+		if !ok {
+			continue
+		}
+		res, err := Patch{op}.Apply(doc)
+		if err != nil {
+			continue
+		}
+		p = append(p, op)
+		doc = res
+	}
+	return p
+}
+
+func randomOperation(r *rand.Rand, doc interface{}, kinds int) (Operation, bool) {
+	var locations [][]string
+	var containers [][]string
+	c := &Compactor{AllNodes: true}
+	c.visit(doc, func(path []string, val interface{}) {
+		if len(path) > 0 {
+			locations = append(locations, path)
+		}
+		if canVisitChildren(val) {
+			containers = append(containers, path)
+		}
+	})
+	sort.Slice(locations, func(i, j int) bool { return comparePaths(locations[i], locations[j]) < 0 })
+	sort.Slice(containers, func(i, j int) bool { return comparePaths(containers[i], containers[j]) < 0 })
+	str := func(path []string) string { return (&Pointer{path: path}).String() }
+	position := func() string {
+		parent := containers[r.Intn(len(containers))]
+		val, _ := getValue(doc, parent)
+		if arr, ok := val.([]interface{}); ok {
+			if r.Intn(4) == 0 {
+				return str(childpath(parent, "-"))
+			}
+			return str(childpath(parent, strconv.Itoa(r.Intn(len(arr)+1))))
+		}
+		return str(childpath(parent, string(rune('a'+r.Intn(4)))))
+	}
+	value := func() interface{} {
+		switch r.Intn(3) {
+		case 0:
+			return float64(r.Intn(100))
+		case 1:
+			return map[string]interface{}{"x": float64(r.Intn(100))}
+		}
+		return []interface{}{float64(r.Intn(100))}
+	}
+	if len(locations) == 0 {
+		return Operation{Op: "add", Path: position(), Value: value()}, true
+	}
+	loc := str(locations[r.Intn(len(locations))])
+	switch r.Intn(kinds) {
+	case 0:
+		return Operation{Op: "add", Path: position(), Value: value()}, true
+	case 1:
+		return Operation{Op: "remove", Path: loc}, true
+	case 2:
+		return Operation{Op: "replace", Path: loc, Value: value()}, true
+	case 3:
+		return Operation{Op: "move", From: loc, Path: position()}, true
+	}
+	return Operation{Op: "copy", From: loc, Path: position()}, true
+}
+
+var randomDocs = []string{
+	`{"a":[1,2,3],"b":{"c":[{"x":1},{"x":2}],"d":4}}`,
+	`[[1,2],[3,4],{"a":[5]}]`,
+	`{"a":{"b":{"c":[1,2,3,4]}},"d":[{"a":1},{"b":2}]}`,
+}
+
+func TestTransform(t *testing.T) {
+	doc := `{"l":[1,2,3],"o":{"x":1}}`
+	// array indices shift
+	assertTransforms(t, doc,
+		`[{"op":"remove","path":"/l/0"}]`,
+		`[{"op":"replace","path":"/l/2","value":9}]`,
+		`[{"op":"remove","path":"/l/0"}]`,
+		`[{"op":"replace","path":"/l/1","value":9}]`)
+	assertTransforms(t, doc,
+		`[{"op":"add","path":"/l/-","value":4}]`,
+		`[{"op":"remove","path":"/l/0"}]`,
+		`[{"op":"add","path":"/l/2","value":4}]`,
+		`[{"op":"remove","path":"/l/0"}]`)
+	assertTransforms(t, doc,
+		`[{"op":"add","path":"/l/1","value":"a"}]`,
+		`[{"op":"add","path":"/l/1","value":"b"}]`,
+		`[{"op":"add","path":"/l/1","value":"a"}]`,
+		`[{"op":"add","path":"/l/2","value":"b"}]`)
+	// removing or replacing a parent wins
+	assertTransforms(t, doc,
+		`[{"op":"replace","path":"/o/x","value":2}]`,
+		`[{"op":"remove","path":"/o"}]`,
+		`[]`,
+		`[{"op":"remove","path":"/o"}]`)
+	assertTransforms(t, doc,
+		`[{"op":"add","path":"/l","value":[]}]`,
+		`[{"op":"remove","path":"/l/0"},{"op":"add","path":"/l/0","value":0}]`,
+		`[{"op":"add","path":"/l","value":[]}]`,
+		`[]`)
+	// a wins when both set the same location
+	assertTransforms(t, doc,
+		`[{"op":"replace","path":"/o/x","value":2}]`,
+		`[{"op":"replace","path":"/o/x","value":3}]`,
+		`[{"op":"replace","path":"/o/x","value":2}]`,
+		`[]`)
+	// patches are transformed one operation at a time
+	assertTransforms(t, doc,
+		`[{"op":"remove","path":"/l/0"},{"op":"remove","path":"/l/0"}]`,
+		`[{"op":"add","path":"/l/0","value":0},{"op":"replace","path":"/l/3","value":9}]`,
+		`[{"op":"remove","path":"/l/1"},{"op":"remove","path":"/l/1"}]`,
+		`[{"op":"add","path":"/l/0","value":0},{"op":"replace","path":"/l/1","value":9}]`)
+}
+
+func TestTransformMove(t *testing.T) {
+	doc := `{"l":[1,2,3],"o":{"x":1}}`
+	// changes follow moved values
+	assertTransforms(t, doc,
+		`[{"op":"move","from":"/o","path":"/p"}]`,
+		`[{"op":"replace","path":"/o/x","value":5}]`,
+		`[{"op":"move","from":"/o","path":"/p"}]`,
+		`[{"op":"replace","path":"/p/x","value":5}]`)
+	assertTransforms(t, doc,
+		`[{"op":"move","from":"/l/0","path":"/l/2"}]`,
+		`[{"op":"replace","path":"/l/1","value":"x"}]`,
+		`[{"op":"move","from":"/l/0","path":"/l/2"}]`,
+		`[{"op":"replace","path":"/l/0","value":"x"}]`)
+	// removing a moved value removes it where it was moved to
+	assertTransforms(t, doc,
+		`[{"op":"move","from":"/o/x","path":"/l/0"}]`,
+		`[{"op":"remove","path":"/o/x"}]`,
+		`[]`,
+		`[{"op":"remove","path":"/l/0"}]`)
+	// moving a value out of one that is removed keeps it
+	assertTransforms(t, doc,
+		`[{"op":"move","from":"/o/x","path":"/y"}]`,
+		`[{"op":"remove","path":"/o"}]`,
+		`[{"op":"add","path":"/y","value":1}]`,
+		`[{"op":"remove","path":"/o"}]`)
+	// when both move the same value, a wins
+	assertTransforms(t, doc,
+		`[{"op":"move","from":"/o","path":"/p"}]`,
+		`[{"op":"move","from":"/o","path":"/q"}]`,
+		`[{"op":"move","from":"/q","path":"/p"}]`,
+		`[]`)
+}
+
+func TestTransformCopy(t *testing.T) {
+	doc := `{"l":[1,2,3],"o":{"x":1}}`
+	// changes to a copied value are made to the copy too
+	assertTransforms(t, doc,
+		`[{"op":"copy","from":"/o","path":"/c"}]`,
+		`[{"op":"replace","path":"/o/x","value":2}]`,
+		`[{"op":"copy","from":"/o","path":"/c"}]`,
+		`[{"op":"replace","path":"/c/x","value":2},{"op":"replace","path":"/o/x","value":2}]`)
+	assertTransforms(t, doc,
+		`[{"op":"copy","from":"/l","path":"/o/l"}]`,
+		`[{"op":"remove","path":"/l/0"}]`,
+		`[{"op":"copy","from":"/l","path":"/o/l"}]`,
+		`[{"op":"remove","path":"/o/l/0"},{"op":"remove","path":"/l/0"}]`)
+	// copying a removed value still copies it
+	assertTransforms(t, doc,
+		`[{"op":"copy","from":"/o","path":"/c"}]`,
+		`[{"op":"remove","path":"/o"}]`,
+		`[{"op":"add","path":"/c","value":{"x":1}}]`,
+		`[{"op":"remove","path":"/o"}]`)
+}
+
+func TestTransformErrors(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"o":{"x":1}}`), &doc)
+	test := Patch{{Op: "test", Path: "/o/x", Value: 1.0}}
+	replace := Patch{{Op: "replace", Path: "/o/x", Value: 2.0}}
+	_, _, err := Transform(doc, test, replace)
+	assert.NotNil(t, err)
+
+	_, _, err = Transform(doc, Patch{{Op: "remove", Path: "/y"}}, replace)
+	assert.NotNil(t, err)
+	_, _, err = Transform(doc, replace, Patch{{Op: "bad", Path: "/o"}})
+	assert.NotNil(t, err)
+
+	a2, b2, err := Transform(doc, test, Patch{{Op: "add", Path: "/y", Value: 1.0}})
+	assert.Nil(t, err)
+	assert.Equal(t, test, a2)
+	assert.Len(t, b2, 1)
+}
+
+func TestTransformRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		var doc interface{}
+		json.Unmarshal([]byte(randomDocs[i%len(randomDocs)]), &doc)
+		assertConverges(t, randomDocs[i%len(randomDocs)], randomPatch(r, doc, 3, 3), randomPatch(r, doc, 3, 3))
+	}
+}
+
+func TestTransformRandomMoves(t *testing.T) {
+	// some moves and copies really conflict, like moving two values into
+	// each other, but nearly all of them can be transformed
+	r := rand.New(rand.NewSource(1))
+	failed := 0
+	for i := 0; i < 3000; i++ {
+		var doc interface{}
+		json.Unmarshal([]byte(randomDocs[i%len(randomDocs)]), &doc)
+		if _, _, err := Transform(doc, randomPatch(r, doc, 1, 5), randomPatch(r, doc, 1, 5)); err != nil {
+			failed++
+		}
+	}
+	assert.True(t, failed < 30, "%d of 3000 failed", failed)
+}
+
+func ExampleTransform() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"todo":["milk","eggs"]}`), &doc)
+	ours := Patch{{Op: "add", Path: "/todo/0", Value: "bread"}}
+	theirs := Patch{{Op: "replace", Path: "/todo/1", Value: "butter"}}
+
+	_, theirs2, _ := Transform(doc, ours, theirs)
+	fmt.Println(theirs2[0].Path)
+
+	doc, _ = ours.Apply(doc)
+	doc, _ = theirs2.Apply(doc)
+	res, _ := json.Marshal(doc)
+	fmt.Println(string(res))
+	// Output:
+	// /todo/2
+	// {"todo":["bread","milk","butter"]}
+}
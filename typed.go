@@ -0,0 +1,194 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// NotFoundError is returned by GetAs when the location does not exist in the
+// document.
+type NotFoundError struct {
+	Pointer string
+	Err     error
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Cannot find '%s': %v", e.Pointer, e.Err)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// TypeError is returned by GetAs when the value at a location can't be
+// converted to the requested type. Type is the name of the requested type.
+type TypeError struct {
+	Pointer string
+	Value   interface{}
+	Type    string
+}
+
+func (e *TypeError) Error() string {
+	switch t := TypeOf(e.Value); t {
+	case BoolType, NumberType, StringType:
+		return fmt.Sprintf("Cannot convert %s %v at '%s' to %s", t, e.Value, e.Pointer, e.Type)
+	case AnyType:
+		return fmt.Sprintf("Cannot convert %T at '%s' to %s", e.Value, e.Pointer, e.Type)
+	default:
+		return fmt.Sprintf("Cannot convert %s at '%s' to %s", t, e.Pointer, e.Type)
+	}
+}
+
+/*
+GetAs returns the value at the location in the document as a T. It returns a
+*NotFoundError if the location does not exist, and a *TypeError if the value
+is not a T.
+
+Numbers are converted between float64, json.Number and go's integer and
+float types, as long as they fit in T without losing precision, so 3.0 can
+be read as an int but 3.5 and 300 can't be read as an int8, and 2^53+1 can't
+be read as a float64. Fractions are read as the nearest float. Other values are
+only returned if they are of type T, so strings are never converted.
+
+    // Given doc is unmarshalled from {"port": 8080, "debug": false}
+    port, err := jsonptr.GetAs[int](doc, jsonptr.MustConstruct("/port"))
+    // port == 8080, err == nil
+    _, err = jsonptr.GetAs[string](doc, jsonptr.MustConstruct("/port"))
+    // err is a *TypeError
+*/
+func GetAs[T any](document interface{}, p *Pointer) (T, error) {
+	var res T
+	val, err := p.Get(document)
+	if err != nil {
+		return res, &NotFoundError{p.String(), err}
+	}
+	if !convert(val, &res) {
+		return res, &TypeError{p.String(), val, reflect.TypeOf(&res).Elem().String()}
+	}
+	return res, nil
+}
+
+// GetOr returns the value at the location in the document as a T like GetAs,
+// or def if the location does not exist. It still returns a *TypeError if
+// the value is not a T.
+func GetOr[T any](document interface{}, p *Pointer, def T) (T, error) {
+	res, err := GetAs[T](document, p)
+	if _, ok := err.(*NotFoundError); ok {
+		return def, nil
+	}
+	return res, err
+}
+
+// convert stores val in the value that target points to, converting numbers
+// when needed, and reports whether it could.
+func convert(val interface{}, target interface{}) bool {
+	if n, ok := target.(*json.Number); ok {
+		switch v := val.(type) {
+		case json.Number:
+			*n = v
+			return true
+		case float64:
+			*n = json.Number(strconv.FormatFloat(v, 'g', -1, 64))
+			return true
+		}
+		if _, ok := numberValue(val); ok {
+			*n = json.Number(fmt.Sprint(val))
+			return true
+		}
+		return false
+	}
+
+	rv := reflect.ValueOf(target).Elem()
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := intValue(val)
+		if !ok || rv.OverflowInt(i) {
+			return false
+		}
+		rv.SetInt(i)
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, ok := uintValue(val)
+		if !ok || rv.OverflowUint(u) {
+			return false
+		}
+		rv.SetUint(u)
+		return true
+	case reflect.Float32, reflect.Float64:
+		f, ok := numberValue(val)
+		if !ok || rv.OverflowFloat(f) || !exactFloat(val, f, rv.Type().Bits()) {
+			return false
+		}
+		rv.SetFloat(f)
+		return true
+	}
+	if val == nil {
+		// only interfaces can hold null
+		return rv.Kind() == reflect.Interface
+	}
+	v := reflect.ValueOf(val)
+	if !v.Type().AssignableTo(rv.Type()) {
+		return false
+	}
+	rv.Set(v)
+	return true
+}
+
+// intValue converts a JSON number to an int64, if it is an integer in range.
+func intValue(val interface{}) (int64, bool) {
+	switch v := val.(type) {
+	case int, int8, int16, int32, int64:
+		return reflect.ValueOf(v).Int(), true
+	case uint, uint8, uint16, uint32, uint64:
+		u := reflect.ValueOf(v).Uint()
+		return int64(u), u <= math.MaxInt64
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i, true
+		}
+	}
+	f, ok := numberValue(val)
+	if !ok || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// exactFloat reports whether f, converted to a float of the given bits, is
+// exactly val when val is an integer. Fractions are rarely exact in binary,
+// so they are always allowed.
+func exactFloat(val interface{}, f float64, bits int) bool {
+	r, ok := numberRat(val)
+	if !ok || !r.IsInt() {
+		return true
+	}
+	if bits == 32 {
+		f = float64(float32(f))
+	}
+	fr, ok := numberRat(f)
+	return ok && fr.Cmp(r) == 0
+}
+
+// uintValue converts a JSON number to a uint64, if it is a non-negative
+// integer in range.
+func uintValue(val interface{}) (uint64, bool) {
+	switch v := val.(type) {
+	case uint, uint8, uint16, uint32, uint64:
+		return reflect.ValueOf(v).Uint(), true
+	case int, int8, int16, int32, int64:
+		i := reflect.ValueOf(v).Int()
+		return uint64(i), i >= 0
+	case json.Number:
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return u, true
+		}
+	}
+	f, ok := numberValue(val)
+	if !ok || f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+		return 0, false
+	}
+	return uint64(f), true
+}
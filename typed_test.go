@@ -0,0 +1,218 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+const typedDoc = `{"s":"str","b":false,"f":3.5,"i":3,"big":9007199254740993,"neg":-1,"n":null,"a":[1],"o":{"x":1}}`
+
+func getTypedDoc(useNumber bool) interface{} {
+	var doc interface{}
+	d := json.NewDecoder(strings.NewReader(typedDoc))
+	if useNumber {
+		d.UseNumber()
+	}
+	d.Decode(&doc)
+	return doc
+}
+
+func TestGetAs(t *testing.T) {
+	for _, useNumber := range []bool{false, true} {
+		doc := getTypedDoc(useNumber)
+
+		s, err := GetAs[string](doc, MustConstruct("/s"))
+		assert.Nil(t, err)
+		assert.Equal(t, "str", s)
+
+		b, err := GetAs[bool](doc, MustConstruct("/b"))
+		assert.Nil(t, err)
+		assert.False(t, b)
+
+		f, err := GetAs[float64](doc, MustConstruct("/f"))
+		assert.Nil(t, err)
+		assert.Equal(t, 3.5, f)
+
+		i, err := GetAs[int](doc, MustConstruct("/i"))
+		assert.Nil(t, err)
+		assert.Equal(t, 3, i)
+
+		i8, err := GetAs[int8](doc, MustConstruct("/neg"))
+		assert.Nil(t, err)
+		assert.Equal(t, int8(-1), i8)
+
+		u, err := GetAs[uint16](doc, MustConstruct("/i"))
+		assert.Nil(t, err)
+		assert.Equal(t, uint16(3), u)
+
+		f32, err := GetAs[float32](doc, MustConstruct("/f"))
+		assert.Nil(t, err)
+		assert.Equal(t, float32(3.5), f32)
+
+		n, err := GetAs[json.Number](doc, MustConstruct("/f"))
+		assert.Nil(t, err)
+		assert.Equal(t, json.Number("3.5"), n)
+
+		d, err := GetAs[time.Duration](doc, MustConstruct("/i"))
+		assert.Nil(t, err)
+		assert.Equal(t, time.Duration(3), d)
+
+		a, err := GetAs[[]interface{}](doc, MustConstruct("/a"))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(a))
+
+		o, err := GetAs[map[string]interface{}](doc, MustConstruct("/o"))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(o))
+
+		v, err := GetAs[interface{}](doc, MustConstruct("/n"))
+		assert.Nil(t, err)
+		assert.Nil(t, v)
+
+		v, err = GetAs[interface{}](doc, MustConstruct("/s"))
+		assert.Nil(t, err)
+		assert.Equal(t, "str", v)
+	}
+}
+
+func TestGetAsLargeIntegers(t *testing.T) {
+	// json.Number keeps integers that don't fit in a float64 exactly
+	i, err := GetAs[int64](getTypedDoc(true), MustConstruct("/big"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9007199254740993), i)
+
+	i, err = GetAs[int64](getTypedDoc(false), MustConstruct("/big"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9007199254740992), i)
+
+	doc := map[string]interface{}{
+		"max":  json.Number("9223372036854775807"),
+		"over": json.Number("9223372036854775808"),
+		"exp":  json.Number("1e3"),
+		"u64":  uint64(1 << 63),
+		"huge": 1e19,
+	}
+	i, err = GetAs[int64](doc, MustConstruct("/max"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9223372036854775807), i)
+	_, err = GetAs[int64](doc, MustConstruct("/over"))
+	assert.NotNil(t, err)
+	u, err := GetAs[uint64](doc, MustConstruct("/over"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(9223372036854775808), u)
+	i, err = GetAs[int64](doc, MustConstruct("/exp"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), i)
+	_, err = GetAs[int64](doc, MustConstruct("/u64"))
+	assert.NotNil(t, err)
+	_, err = GetAs[int64](doc, MustConstruct("/huge"))
+	assert.NotNil(t, err)
+	u, err = GetAs[uint64](doc, MustConstruct("/huge"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1e19), u)
+
+	f, err := GetAs[float64](doc, MustConstruct("/max"))
+	assert.NotNil(t, err)
+	_, ok := err.(*TypeError)
+	assert.True(t, ok)
+	_, err = GetAs[float64](map[string]interface{}{"n": int64(9007199254740993)}, MustConstruct("/n"))
+	assert.NotNil(t, err)
+	_, err = GetAs[float64](getTypedDoc(true), MustConstruct("/big"))
+	assert.NotNil(t, err)
+	f, err = GetAs[float64](doc, MustConstruct("/u64"))
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1<<63), f)
+	_, err = GetAs[float32](map[string]interface{}{"n": json.Number("16777217")}, MustConstruct("/n"))
+	assert.NotNil(t, err)
+	f32, err := GetAs[float32](map[string]interface{}{"n": json.Number("0.1")}, MustConstruct("/n"))
+	assert.Nil(t, err)
+	assert.Equal(t, float32(0.1), f32)
+
+	n, err := GetAs[json.Number](doc, MustConstruct("/u64"))
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("9223372036854775808"), n)
+}
+
+func TestGetAsTypeErrors(t *testing.T) {
+	doc := getTypedDoc(false)
+	cases := []struct {
+		ptr, message string
+		get          func(*Pointer) error
+	}{
+		{"/i", "Cannot convert number 3 at '/i' to string", func(p *Pointer) error { _, err := GetAs[string](doc, p); return err }},
+		{"/s", "Cannot convert string str at '/s' to float64", func(p *Pointer) error { _, err := GetAs[float64](doc, p); return err }},
+		{"/f", "Cannot convert number 3.5 at '/f' to int", func(p *Pointer) error { _, err := GetAs[int](doc, p); return err }},
+		{"/big", "Cannot convert number 9.007199254740992e+15 at '/big' to int32", func(p *Pointer) error { _, err := GetAs[int32](doc, p); return err }},
+		{"/neg", "Cannot convert number -1 at '/neg' to uint", func(p *Pointer) error { _, err := GetAs[uint](doc, p); return err }},
+		{"/b", "Cannot convert bool false at '/b' to json.Number", func(p *Pointer) error { _, err := GetAs[json.Number](doc, p); return err }},
+		{"/n", "Cannot convert null at '/n' to bool", func(p *Pointer) error { _, err := GetAs[bool](doc, p); return err }},
+		{"/n", "Cannot convert null at '/n' to []interface {}", func(p *Pointer) error { _, err := GetAs[[]interface{}](doc, p); return err }},
+		{"/o", "Cannot convert object at '/o' to []interface {}", func(p *Pointer) error { _, err := GetAs[[]interface{}](doc, p); return err }},
+		{"/a", "Cannot convert array at '/a' to []string", func(p *Pointer) error { _, err := GetAs[[]string](doc, p); return err }},
+	}
+	for _, c := range cases {
+		err := c.get(MustConstruct(c.ptr))
+		var typeErr *TypeError
+		if assert.True(t, errors.As(err, &typeErr), "%s: %v", c.ptr, err) {
+			assert.Equal(t, c.ptr, typeErr.Pointer)
+			assert.Equal(t, c.message, err.Error())
+		}
+	}
+}
+
+func TestGetAsNotFound(t *testing.T) {
+	doc := getTypedDoc(false)
+	for _, ptr := range []string{"/missing", "/a/1", "/s/x", "/a/-"} {
+		_, err := GetAs[string](doc, MustConstruct(ptr))
+		var notFound *NotFoundError
+		if assert.True(t, errors.As(err, &notFound), "%s: %v", ptr, err) {
+			assert.Equal(t, ptr, notFound.Pointer)
+			assert.NotNil(t, errors.Unwrap(err))
+		}
+	}
+	_, err := GetAs[string](doc, MustConstruct("/missing"))
+	assert.Equal(t, "Cannot find '/missing': Map had no key when evaluating path segment 'missing'", err.Error())
+}
+
+func TestGetOr(t *testing.T) {
+	doc := getTypedDoc(false)
+
+	s, err := GetOr(doc, MustConstruct("/missing"), "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", s)
+
+	s, err = GetOr(doc, MustConstruct("/s"), "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "str", s)
+
+	// a false value is not a missing one
+	b, err := GetOr(doc, MustConstruct("/b"), true)
+	assert.Nil(t, err)
+	assert.False(t, b)
+
+	i, err := GetOr(doc, MustConstruct("/s"), 8080)
+	assert.Equal(t, 0, i)
+	_, ok := err.(*TypeError)
+	assert.True(t, ok)
+}
+
+func ExampleGetAs() {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"port": 8080, "debug": false}`), &doc)
+
+	port, err := GetAs[int](doc, MustConstruct("/port"))
+	fmt.Println(port, err)
+	_, err = GetAs[string](doc, MustConstruct("/port"))
+	fmt.Println(err)
+	_, err = GetAs[bool](doc, MustConstruct("/verbose"))
+	fmt.Println(err)
+	// Output:
+	// 8080 <nil>
+	// Cannot convert number 8080 at '/port' to string
+	// Cannot find '/verbose': Map had no key when evaluating path segment 'verbose'
+}
@@ -0,0 +1,181 @@
+package jsonptr
+
+import (
+	"sync/atomic"
+)
+
+// Event describes a change to a watched Document. Op is "add", "remove" or
+// "replace", and Pointer is the location that changed, with any trailing "-"
+// replaced by the index of the appended element. Old is nil when a value is
+// added, and New is nil when one is removed.
+//
+// Old and New are shared by every Subscription that receives the event, and
+// must not be changed.
+type Event struct {
+	Op      string
+	Pointer *Pointer
+	Old     interface{}
+	New     interface{}
+}
+
+// Subscription receives the Events for changes to a Document that affect a
+// location. See Document.Watch.
+type Subscription struct {
+	// C receives the events. It is closed by Unsubscribe.
+	C <-chan Event
+
+	c       chan Event
+	pattern []string
+	doc     *Document
+	dropped int64
+}
+
+// Watch returns a Subscription that receives an Event for every Set, Force,
+// Delete or Patch that changes a location matching the pattern, one of its
+// descendants, or one of its ancestors. A "*" segment in the pattern matches
+// any key or index, so a pattern of "/users/*/name" receives the changes to
+// every user's name, as well as users being added and removed.
+//
+// Events are sent on a channel with room for buffer events, without waiting
+// for the subscriber. Events that don't fit are dropped and counted, see
+// Dropped. Inserting or removing an array element only sends an event for
+// that element, not for the elements it shifts.
+//
+//     sub := doc.Watch(jsonptr.MustConstruct("/settings/theme"), 16)
+//     defer sub.Unsubscribe()
+//     for ev := range sub.C {
+//         // ev.Pointer, ev.Old and ev.New describe the change
+//     }
+func (d *Document) Watch(pattern *Pointer, buffer int) *Subscription {
+	c := make(chan Event, buffer)
+	s := &Subscription{C: c, c: c, pattern: pattern.path, doc: d}
+	d.watchMu.Lock()
+	d.subs = append(d.subs, s)
+	d.watchMu.Unlock()
+	return s
+}
+
+// Unsubscribe stops the subscription and closes C. It can be called more than
+// once.
+func (s *Subscription) Unsubscribe() {
+	d := s.doc
+	d.watchMu.Lock()
+	defer d.watchMu.Unlock()
+	for i, sub := range d.subs {
+		if sub == s {
+			d.subs = append(d.subs[:i:i], d.subs[i+1:]...)
+			close(s.c)
+			return
+		}
+	}
+}
+
+// Dropped returns the number of events that were dropped because C was full.
+func (s *Subscription) Dropped() int {
+	return int(atomic.LoadInt64(&s.dropped))
+}
+
+// watching returns true if the document has any subscriptions.
+func (d *Document) watching() bool {
+	d.watchMu.RLock()
+	defer d.watchMu.RUnlock()
+	return len(d.subs) > 0
+}
+
+// notify sends the events to the subscriptions they affect.
+func (d *Document) notify(events ...Event) {
+	d.watchMu.RLock()
+	defer d.watchMu.RUnlock()
+	for _, ev := range events {
+		for _, s := range d.subs {
+			if !matchPattern(s.pattern, ev.Pointer.path) {
+				continue
+			}
+			select {
+			case s.c <- ev:
+			default:
+				atomic.AddInt64(&s.dropped, 1)
+			}
+		}
+	}
+}
+
+// matchPattern returns true if path is a location matching the pattern, or
+// one of its ancestors or descendants.
+func matchPattern(pattern, path []string) bool {
+	for i := 0; i < len(pattern) && i < len(path); i++ {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// changeEvent returns the event for setting the value at p in the document,
+// before the change is made. When insert is true, setting an array element
+// inserts a new element instead of replacing it.
+func changeEvent(document interface{}, p *Pointer, insert bool) Event {
+	if insert && !p.IsRoot() && isArray(getOrNil(p.Parent(), document)) {
+		return Event{Op: "add", Pointer: p}
+	}
+	old, err := p.Get(document)
+	if err != nil {
+		return Event{Op: "add", Pointer: p}
+	}
+	return Event{Op: "replace", Pointer: p, Old: deepCopy(old)}
+}
+
+// removeEvent returns the event for removing the value at p from the
+// document, before it is removed.
+func removeEvent(document interface{}, p *Pointer) Event {
+	return Event{Op: "remove", Pointer: p, Old: deepCopy(getOrNil(p, document))}
+}
+
+// patchEvents returns the events for applying an operation to the document,
+// before it is applied. The operation is assumed to be valid.
+func patchEvents(document interface{}, op Operation) []Event {
+	path, err := New(op.Path)
+	if err != nil {
+		return nil
+	}
+	switch op.Op {
+	case "add", "copy":
+		return []Event{changeEvent(document, path, true)}
+	case "replace":
+		return []Event{changeEvent(document, path, false)}
+	case "remove":
+		return []Event{removeEvent(document, path)}
+	case "move":
+		from, err := New(op.From)
+		if err != nil || op.From == op.Path {
+			return nil
+		}
+		return []Event{removeEvent(document, from), changeEvent(document, path, true)}
+	}
+	return nil
+}
+
+// applied completes the event with the new value from the document, after
+// the change is made.
+func (ev Event) applied(document interface{}) Event {
+	if ev.Op == "remove" {
+		return ev
+	}
+	if ev.Pointer.Last() == "-" {
+		if arr, ok := getOrNil(ev.Pointer.Parent(), document).([]interface{}); ok {
+			ev.Pointer = ev.Pointer.Parent().Index(len(arr) - 1)
+		}
+	}
+	ev.New = deepCopy(getOrNil(ev.Pointer, document))
+	return ev
+}
+
+func getOrNil(p *Pointer, document interface{}) interface{} {
+	val, _ := p.Get(document)
+	return val
+}
+
+func isArray(val interface{}) bool {
+	_, ok := val.([]interface{})
+	return ok
+}
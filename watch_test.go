@@ -0,0 +1,151 @@
+package jsonptr
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func drain(s *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case ev := <-s.C:
+			events = append(events, ev)
+		default:
+			return events
+		}
+	}
+}
+
+func TestWatchSetAndDelete(t *testing.T) {
+	d := NewDocument(map[string]interface{}{"settings": map[string]interface{}{"theme": "dark"}})
+	theme := d.Watch(MustConstruct("/settings/theme"), 10)
+	all := d.Watch(MustConstruct(""), 10)
+	other := d.Watch(MustConstruct("/other"), 10)
+
+	d.Set(MustConstruct("/settings/theme"), "light")
+	d.Set(MustConstruct("/settings/font"), "mono")
+	d.Delete(MustConstruct("/settings/theme"))
+	d.Set(MustConstruct("/settings"), map[string]interface{}{"theme": "blue"})
+	assert.Error(t, d.Set(MustConstruct("/missing/x"), 1.0))
+
+	assert.Equal(t, []Event{
+		{Op: "replace", Pointer: MustConstruct("/settings/theme"), Old: "dark", New: "light"},
+		{Op: "remove", Pointer: MustConstruct("/settings/theme"), Old: "light"},
+		{Op: "replace", Pointer: MustConstruct("/settings"), Old: map[string]interface{}{"font": "mono"}, New: map[string]interface{}{"theme": "blue"}},
+	}, drain(theme))
+	assert.Len(t, drain(all), 4)
+	assert.Empty(t, drain(other))
+}
+
+func TestWatchPatterns(t *testing.T) {
+	assert.True(t, matchPattern([]string{"users", "*", "name"}, []string{"users", "3", "name"}))
+	assert.True(t, matchPattern([]string{"users", "*", "name"}, []string{"users", "3"}))
+	assert.True(t, matchPattern([]string{"users", "*", "name"}, []string{"users", "3", "name", "first"}))
+	assert.False(t, matchPattern([]string{"users", "*", "name"}, []string{"users", "3", "email"}))
+	assert.False(t, matchPattern([]string{"users"}, []string{"groups", "0"}))
+	assert.True(t, matchPattern(nil, []string{"groups", "0"}))
+
+	d := NewDocument(map[string]interface{}{"users": []interface{}{}})
+	names := d.Watch(MustConstruct("/users/*/name"), 10)
+	d.Set(MustConstruct("/users/-"), map[string]interface{}{"name": "jo"})
+	d.Force(MustConstruct("/users/0/email"), "jo@example.com")
+	d.Force(MustConstruct("/users/0/name"), "joe")
+
+	assert.Equal(t, []Event{
+		{Op: "add", Pointer: MustConstruct("/users/0"), New: map[string]interface{}{"name": "jo"}},
+		{Op: "replace", Pointer: MustConstruct("/users/0/name"), Old: "jo", New: "joe"},
+	}, drain(names))
+}
+
+func TestWatchPatch(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{"a": [1, 2], "b": {"c": 3}}`), &doc)
+	d := NewDocument(doc)
+	s := d.Watch(MustConstruct(""), 10)
+
+	assert.Nil(t, d.Patch(Patch{
+		{Op: "add", Path: "/a/0", Value: 0.0},
+		{Op: "replace", Path: "/b/c", Value: 4.0},
+		{Op: "move", From: "/b", Path: "/a/-"},
+		{Op: "test", Path: "/a/0", Value: 0.0},
+		{Op: "copy", From: "/a/1", Path: "/d"},
+		{Op: "remove", Path: "/a/2"},
+	}))
+	assert.Equal(t, []Event{
+		{Op: "add", Pointer: MustConstruct("/a/0"), New: 0.0},
+		{Op: "replace", Pointer: MustConstruct("/b/c"), Old: 3.0, New: 4.0},
+		{Op: "remove", Pointer: MustConstruct("/b"), Old: map[string]interface{}{"c": 4.0}},
+		{Op: "add", Pointer: MustConstruct("/a/3"), New: map[string]interface{}{"c": 4.0}},
+		{Op: "add", Pointer: MustConstruct("/d"), New: 1.0},
+		{Op: "remove", Pointer: MustConstruct("/a/2"), Old: 2.0},
+	}, drain(s))
+
+	assert.Error(t, d.Patch(Patch{{Op: "remove", Path: "/a/0"}, {Op: "remove", Path: "/missing"}}))
+	assert.Empty(t, drain(s))
+}
+
+func TestWatchDropsAndUnsubscribe(t *testing.T) {
+	d := NewDocument(map[string]interface{}{})
+	s := d.Watch(MustConstruct("/n"), 2)
+	for i := 0; i < 5; i++ {
+		d.Set(MustConstruct("/n"), float64(i))
+	}
+	assert.Equal(t, 3, s.Dropped())
+	assert.Len(t, drain(s), 2)
+
+	s.Unsubscribe()
+	s.Unsubscribe()
+	_, ok := <-s.C
+	assert.False(t, ok)
+	assert.Nil(t, d.Set(MustConstruct("/n"), 6.0))
+	assert.False(t, d.watching())
+}
+
+func TestWatchConcurrent(t *testing.T) {
+	d := NewDocument(map[string]interface{}{"a": map[string]interface{}{}, "b": map[string]interface{}{}})
+	s := d.Watch(MustConstruct("/a"), 1000)
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				d.Set(MustConstruct("/"+key+"/n"), float64(i))
+			}
+		}(key)
+	}
+	wg.Wait()
+	s.Unsubscribe()
+
+	var last float64 = -1
+	count := 0
+	for ev := range s.C {
+		assert.Equal(t, "/a/n", ev.Pointer.String())
+		assert.Equal(t, last+1, ev.New)
+		last = ev.New.(float64)
+		count++
+	}
+	assert.Equal(t, 100, count)
+}
+
+func ExampleDocument_Watch() {
+	d := NewDocument(map[string]interface{}{"settings": map[string]interface{}{"theme": "dark"}})
+	sub := d.Watch(MustConstruct("/settings/theme"), 8)
+	defer sub.Unsubscribe()
+
+	d.Set(MustConstruct("/settings/theme"), "light")
+	d.Set(MustConstruct("/settings/font"), "mono")
+	d.Delete(MustConstruct("/settings"))
+
+	for len(sub.C) > 0 {
+		ev := <-sub.C
+		fmt.Println(ev.Op, ev.Pointer, ev.Old, ev.New)
+	}
+	// Output:
+	// replace /settings/theme dark light
+	// remove /settings map[font:mono theme:light] <nil>
+}